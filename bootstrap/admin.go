@@ -0,0 +1,101 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// MetricsPath 是 Prometheus 指标端点的路径
+	MetricsPath = "/metrics"
+	// ReadyPath 是就绪探针端点的建议路径，关停排空期间返回 503
+	ReadyPath = "/readyz"
+	// HealthPath 是依赖健康检查端点的建议路径，报告由 AddHealthCheck 注册的外部依赖的连通性
+	HealthPath = "/healthz"
+)
+
+// healthCheckTimeout 是健康检查端点中单个依赖检查的超时
+const healthCheckTimeout = 2 * time.Second
+
+// mountOperationalHandlers 按需在服务 mux 上挂载标准的运维端点
+func mountOperationalHandlers(mux *http.ServeMux, enableProfiling, enableMetrics bool) {
+	if enableProfiling {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	if enableMetrics {
+		mux.Handle(MetricsPath, promhttp.Handler())
+	}
+}
+
+// ReadinessHandler 返回就绪探针端点，应用进入关停排空阶段后返回 503，否则返回 200。
+// AddServer 不会自动挂载它，需要时由业务方挂载到自己的 mux 上，避免与已有路由冲突:
+//
+//	mux.Handle(bootstrap.ReadyPath, app.ReadinessHandler())
+func (app *Application) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.draining.Load() {
+			http.Error(w, "draining", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+// healthCheck 是一个具名的依赖连通性检查
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// AddHealthCheck 注册一个由 HealthHandler 执行的依赖检查，例如 Redis 或 Nacos:
+//
+//	app.AddHealthCheck("redis", redisClient.Ping)
+//	app.AddHealthCheck("nacos", appCtx.NamingClient.Ping)
+//
+// 只注册真正影响本实例服务能力的依赖: 任意检查失败都会让该实例的健康检查返回 503，
+// 共享依赖(例如 Nacos)的一次抖动会让所有实例同时被摘除。
+func (app *Application) AddHealthCheck(name string, check func(ctx context.Context) error) {
+	app.healthMu.Lock()
+	defer app.healthMu.Unlock()
+	app.healthChecks = append(app.healthChecks, healthCheck{name: name, check: check})
+}
+
+// HealthHandler 返回依赖健康检查端点，逐一执行 AddHealthCheck 注册的检查，
+// 全部正常时返回 200，否则返回 503，响应体为各依赖的状态。没有注册任何检查时总是返回 200。
+// AddServer 不会自动挂载它，需要时由业务方挂载到自己的 mux 上，避免与已有路由冲突:
+//
+//	mux.Handle(bootstrap.HealthPath, app.HealthHandler())
+func (app *Application) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.healthMu.Lock()
+		checks := append([]healthCheck(nil), app.healthChecks...)
+		app.healthMu.Unlock()
+
+		status := map[string]string{}
+		code := http.StatusOK
+		for _, hc := range checks {
+			ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+			err := hc.check(ctx)
+			cancel()
+			if err != nil {
+				status[hc.name] = err.Error()
+				code = http.StatusServiceUnavailable
+			} else {
+				status[hc.name] = "ok"
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(status)
+	})
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"github.com/wangyingjie930/nexus-pkg/logger"
 	"github.com/wangyingjie930/nexus-pkg/nacos"
-	"github.com/wangyingjie930/nexus-pkg/tracing"
 	"github.com/wangyingjie930/nexus-pkg/utils"
 	"net/http"
 	"os"
@@ -25,12 +24,18 @@ type AppInfo struct {
 	ServiceName      string
 	Port             int
 	RegisterHandlers func(appCtx AppCtx) // 一个函数，允许每个服务注册自己独特的 HTTP 路由
+	EnableProfiling  bool                // 是否挂载 /debug/pprof
+	EnableMetrics    bool                // 是否挂载 Prometheus /metrics
+	ConfigOptions    LoadOptions         // 配置加载选项，例如配置热更新回调
+	// DisableRegistration 为 true 时不向 Nacos 注册实例，配置仍然从 Nacos 加载，
+	// Nacos 客户端仍会创建并传给 RegisterHandlers 用于发现其他服务。适用于部署在静态入口之后的服务。
+	DisableRegistration bool
 }
 
 // StartService 封装了所有微服务的通用启动和优雅关停逻辑。
 func StartService(info AppInfo) {
 	// 首先，初始化配置（它会决定是否使用本地文件模式）
-	Init()
+	Load(info.ConfigOptions)
 	logger.Init(info.ServiceName)
 
 	var namingClient *nacos.Client
@@ -55,14 +60,18 @@ func StartService(info AppInfo) {
 	}
 
 	// 初始化 Tracer
-	tp, err := tracing.InitTracerProvider(info.ServiceName, GetCurrentConfig().Infra.Jaeger.Endpoint)
+	tp, err := initTracerProvider(info.ServiceName)
 	if err != nil {
 		logger.Logger.Fatal().Msgf("failed to initialize tracer provider: %v", err)
 	}
 
-	// 只有在非本地模式下才获取IP并注册服务
+	// 只有在非本地模式且未禁用注册时才获取IP并注册服务
+	registered := !isLocalMode && namingClient != nil && !info.DisableRegistration
+	if !isLocalMode && info.DisableRegistration {
+		logger.Logger.Info().Msg("Nacos registration is disabled, instance will not be registered.")
+	}
 	var ip string
-	if !isLocalMode && namingClient != nil {
+	if registered {
 		ip, err = utils.GetOutboundIP()
 		if err != nil {
 			logger.Logger.Fatal().Msgf("failed to get outbound IP address: %v", err)
@@ -75,6 +84,7 @@ func StartService(info AppInfo) {
 
 	// 创建并启动 HTTP Server
 	mux := http.NewServeMux()
+	mountOperationalHandlers(mux, info.EnableProfiling, info.EnableMetrics)
 	if info.RegisterHandlers != nil {
 		// 即使Nacos为nil，也要将它传递下去，让业务代码决定如何处理
 		info.RegisterHandlers(AppCtx{Mux: mux, Nacos: namingClient})
@@ -90,8 +100,20 @@ func StartService(info AppInfo) {
 	// 优雅关停
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	<-quit
+	// SIGHUP 用于在文件模式下重新加载配置，见 reloadConfigFromFile
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+wait:
+	for {
+		select {
+		case <-hup:
+			reloadConfigFromFile()
+		case <-quit:
+			break wait
+		}
+	}
 	logger.Logger.Printf("Shutting down service %s...", info.ServiceName)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -99,10 +121,12 @@ func StartService(info AppInfo) {
 
 	// 只有在非本地模式下才执行注销和关闭客户端
 	if !isLocalMode && namingClient != nil {
-		if err := namingClient.DeregisterServiceInstance(info.ServiceName, ip, info.Port); err != nil {
-			logger.Logger.Printf("Error deregistering from Nacos: %v", err)
-		} else {
-			logger.Logger.Printf("Service %s deregistered from Nacos.", info.ServiceName)
+		if registered {
+			if err := namingClient.DeregisterServiceInstance(info.ServiceName, ip, info.Port); err != nil {
+				logger.Logger.Printf("Error deregistering from Nacos: %v", err)
+			} else {
+				logger.Logger.Printf("Service %s deregistered from Nacos.", info.ServiceName)
+			}
 		}
 		if nacosConfigClient != nil {
 			nacosConfigClient.CloseClient()
@@ -6,12 +6,13 @@ import (
 	"fmt"
 	"github.com/wangyingjie930/nexus-pkg/logger"
 	"github.com/wangyingjie930/nexus-pkg/nacos"
-	"github.com/wangyingjie930/nexus-pkg/tracing"
 	"github.com/wangyingjie930/nexus-pkg/utils"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -26,18 +27,40 @@ import (
 type AppContext struct {
 	NamingClient   *nacos.Client
 	TracerProvider *sdktrace.TracerProvider
+	// Context 在应用开始关停时被取消，与 Application.Context 相同，
+	// 组装出的依赖(例如消费者)可以据此绑定应用的生命周期
+	Context context.Context
 }
 
 // AppInfoV2 描述了如何构建和运行一个服务。
 // 它是一个泛型结构，允许每个服务定义自己独特的依赖集合。
 type AppInfoV2[T any] struct {
 	ServiceName string
+	// Migrate (可选)负责执行数据库迁移，在配置加载之后、Assemble 之前执行，
+	// 此时尚未创建 Nacos 客户端，也没有启动任何服务器。迁移失败时 NewApplication 直接返回错误，
+	// 不会出现实例已注册到 Nacos 却因表结构不匹配而崩溃的情况。
+	Migrate func() error
 	// Assemble 负责使用 AppContext 创建并组装所有业务依赖。
 	// 这是整个应用的“组装根”（Composition Root）。
 	Assemble func(appCtx AppContext) (T, error)
 	// Register 负责将组装好的业务依赖注册到应用生命周期中，
 	// 例如启动HTTP服务器、启动Kafka消费者等。
 	Register func(app *Application, deps T) error
+	// EnableProfiling 为 true 时，AddServer 会在服务 mux 上挂载 /debug/pprof
+	EnableProfiling bool
+	// EnableMetrics 为 true 时，AddServer 会在服务 mux 上挂载 Prometheus /metrics
+	EnableMetrics bool
+	// ConfigOptions 配置加载选项，例如配置热更新回调
+	ConfigOptions LoadOptions
+	// DrainDelay 为关停时从 Nacos 注销之后、关闭 HTTP 服务器之前的等待时间。
+	// 期间 ReadinessHandler 返回 503，服务器继续处理请求，让负载均衡和调用方有时间感知实例下线，避免滚动发布时出现 502。
+	DrainDelay time.Duration
+	// ReRegisterInterval 大于 0 时，Nacos 客户端会按该间隔在后台重新注册实例，
+	// 使实例在 Nacos 故障恢复后无需重启即可重新被发现
+	ReRegisterInterval time.Duration
+	// DisableRegistration 为 true 时 AddServer 不向 Nacos 注册实例，配置仍然从 Nacos 加载，
+	// AppContext.NamingClient 仍可用于发现其他服务。适用于部署在静态入口之后、不需要被发现的服务。
+	DisableRegistration bool
 }
 
 // Application 是管理整个服务生命周期的核心结构体。
@@ -46,40 +69,84 @@ type Application struct {
 	serviceName string
 	nacosConfig config_client.IConfigClient
 	nacosNaming *nacos.Client
+	// 为 false 时 AddServer 不向 Nacos 注册实例，见 AppInfoV2.DisableRegistration
+	registerInstance bool
 
 	tracer     *sdktrace.TracerProvider
 	httpServer *http.Server
+	// 由 HTTP 服务器劫持的长连接(例如 WebSocket)，在服务器关停开始时被优雅关闭
+	conns *ConnTracker
+
+	enableProfiling bool
+	enableMetrics   bool
+
+	drainDelay time.Duration
+	// 关停开始后置为 true，ReadinessHandler 随之返回 503
+	draining atomic.Bool
+
+	// 由 AddHealthCheck 注册的依赖检查，HealthHandler 会逐一执行
+	healthMu     sync.Mutex
+	healthChecks []healthCheck
 
 	g              *errgroup.Group
 	shutdownCtx    context.Context
 	shutdownCancel context.CancelFunc
+	shutdown       shutdownSequence
+	// 未命名任务的计数器，用于生成默认任务名
+	taskSeq int
 }
 
 // NewApplication 是应用的构造函数，负责完成所有组件的初始化、组装和注册。
+//
+// 迁移说明: 从 v1 StartService 迁移到 v2 Application 时，两条路径可以在同一进程中短暂共存。
+// logger.Init 与 tracing.InitTracerProvider 都是幂等的，后初始化的一方会复用已有的
+// Logger 和 TracerProvider，而不会重复创建 exporter。迁移完成后应只保留 NewApplication。
 func NewApplication[T any](info AppInfoV2[T]) (*Application, error) {
 	// 1. 初始化最底层的配置，并获取 Nacos Config Client
-	Init()
+	Load(info.ConfigOptions)
 
 	// 1.1 初始化日志
 	logger.Init(info.ServiceName)
 
 	// 2. 初始化 Tracer Provider
-	tp, err := tracing.InitTracerProvider(info.ServiceName, GetCurrentConfig().Infra.Jaeger.Endpoint)
+	tp, err := initTracerProvider(info.ServiceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init tracer: %w", err)
 	}
 
+	// 3. 执行数据库迁移
+	if info.Migrate != nil {
+		logger.Logger.Printf("Running database migrations for '%s'...", info.ServiceName)
+		if err := info.Migrate(); err != nil {
+			if shutdownErr := tp.Shutdown(context.Background()); shutdownErr != nil {
+				logger.Logger.Warn().Err(shutdownErr).Msg("⚠️ Failed to shut down tracer provider after migration failure")
+			}
+			return nil, fmt.Errorf("failed to run migrations: %w", err)
+		}
+		logger.Logger.Printf("✅ Database migrations for '%s' completed.", info.ServiceName)
+	}
+
 	serverConfigs, err := createNacosServerConfigs(nacosServerAddrs)
 	if err != nil {
 		logger.Logger.Fatal().Err(err).Msgf("FATAL: Invalid Nacos server address")
 	}
 	clientConfig := createNacosClientConfig(nacosNamespace)
 
-	namingClient, err := nacos.NewNacosClientWithConfigs(serverConfigs, &clientConfig, nacosGroup)
+	var namingOpts []nacos.ClientOption
+	if info.ReRegisterInterval > 0 {
+		namingOpts = append(namingOpts, nacos.WithReRegistration(info.ReRegisterInterval))
+	}
+	namingClient, err := nacos.NewNacosClientWithConfigs(serverConfigs, &clientConfig, nacosGroup, namingOpts...)
 	if err != nil {
 		logger.Logger.Fatal().Err(err).Msgf("failed to initialize nacos client: %v", err)
 	}
 
+	return newApplication(info, tp, namingClient)
+}
+
+// newApplication 在配置、TracerProvider 与 Nacos 客户端就绪后创建 Application，并执行 Assemble 与 Register。
+// 任何一步失败时都会先释放已经创建的资源，再返回错误。
+func newApplication[T any](info AppInfoV2[T], tp *sdktrace.TracerProvider, namingClient *nacos.Client) (*Application, error) {
 	// 4. 创建 Application 实例
 	app := &Application{
 		info:        info,
@@ -87,6 +154,13 @@ func NewApplication[T any](info AppInfoV2[T]) (*Application, error) {
 		nacosConfig: nacosConfigClient,
 		nacosNaming: namingClient,
 		tracer:      tp,
+
+		enableProfiling: info.EnableProfiling,
+		enableMetrics:   info.EnableMetrics,
+		drainDelay:      info.DrainDelay,
+		conns:           NewConnTracker(),
+
+		registerInstance: !info.DisableRegistration,
 	}
 	app.shutdownCtx, app.shutdownCancel = context.WithCancel(context.Background())
 	app.g, _ = errgroup.WithContext(app.shutdownCtx)
@@ -95,13 +169,16 @@ func NewApplication[T any](info AppInfoV2[T]) (*Application, error) {
 	deps, err := info.Assemble(AppContext{
 		NamingClient:   app.nacosNaming,
 		TracerProvider: app.tracer,
+		Context:        app.shutdownCtx,
 	})
 	if err != nil {
+		app.abort()
 		return nil, fmt.Errorf("failed to assemble dependencies: %w", err)
 	}
 
 	// 6. 调用业务方的 Register 函数，注册所有需要运行的服务
 	if err := info.Register(app, deps); err != nil {
+		app.abort()
 		return nil, fmt.Errorf("failed to register services: %w", err)
 	}
 
@@ -112,88 +189,181 @@ func NewApplication[T any](info AppInfoV2[T]) (*Application, error) {
 }
 
 // AddServer 注册一个需要优雅关停的 HTTP 服务器，并将其与 Nacos 服务发现集成。
-func (app *Application) AddServer(mux *http.ServeMux, port int) error {
+// opts 会透传给 Nacos 注册，例如使用 nacos.WithEphemeral(false) 注册持久实例。
+func (app *Application) AddServer(mux *http.ServeMux, port int, opts ...nacos.RegisterOption) error {
 	serviceName := app.serviceName
 	ip, err := utils.GetOutboundIP()
 	if err != nil {
 		return fmt.Errorf("failed to get outbound IP for service %s: %w", serviceName, err)
 	}
 
+	mountOperationalHandlers(mux, app.enableProfiling, app.enableMetrics)
+
 	app.httpServer = &http.Server{
 		Addr:    ":" + strconv.Itoa(port),
 		Handler: mux,
 	}
+	// Shutdown 不会处理被劫持的连接，关停开始时由 ConnTracker 通知它们优雅关闭
+	app.httpServer.RegisterOnShutdown(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := app.conns.CloseAll(ctx); err != nil {
+			logger.Logger.Warn().Err(err).Msg("⚠️ Failed to close some long-lived connections gracefully")
+		}
+	})
 
 	// 启动 HTTP 服务器前，先向 Nacos 注册
-	logger.Logger.Printf("Registering service '%s' to Nacos...", serviceName)
-	if err := app.nacosNaming.RegisterServiceInstance(serviceName, ip, port); err != nil {
-		return fmt.Errorf("failed to register '%s' with nacos: %w", serviceName, err)
+	if app.registerInstance {
+		logger.Logger.Printf("Registering service '%s' to Nacos...", serviceName)
+		if err := app.nacosNaming.RegisterServiceInstance(serviceName, ip, port, opts...); err != nil {
+			return fmt.Errorf("failed to register '%s' with nacos: %w", serviceName, err)
+		}
+		logger.Logger.Printf("✅ Service '%s' registered to Nacos successfully (%s:%d)", serviceName, ip, port)
+	} else {
+		logger.Logger.Info().Str("service", serviceName).Msg("ℹ️ Nacos registration is disabled, instance will not be registered")
 	}
-	logger.Logger.Printf("✅ Service '%s' registered to Nacos successfully (%s:%d)", serviceName, ip, port)
 
 	// 将 HTTP 服务器的启动和关闭纳入 errgroup 的管理
-	app.g.Go(func() error {
+	app.goTask(func() error {
 		logger.Logger.Printf("✅ HTTP server for '%s' listening on :%d", serviceName, port)
 		if err := app.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			return fmt.Errorf("http server error for '%s': %w", serviceName, err)
+			return fmt.Errorf("http server error for '%s' on :%d: %w", serviceName, port, err)
 		}
 		return nil
 	})
 
-	app.g.Go(func() error {
-		<-app.shutdownCtx.Done() // 等待关停信号
-		logger.Logger.Printf("Shutting down HTTP server for '%s'...", serviceName)
-
-		// 创建一个有超时的上下文用于关停
-		shutdownTimeoutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		// 先从 Nacos 注销
-		if err := app.nacosNaming.DeregisterServiceInstance(serviceName, ip, port); err != nil {
-			logger.Logger.Fatal().Msgf("❌ Error deregistering '%s' from Nacos: %v", serviceName, err)
-			// 即使注销失败，也要继续关闭服务器，但记录错误
-		} else {
-			logger.Logger.Printf("✅ Service '%s' deregistered from Nacos.", serviceName)
-		}
+	serverName := fmt.Sprintf("http-server :%d", port)
+	// 先从 Nacos 注销，并在关闭服务器前留出排空时间
+	app.shutdown.add(stopHook{
+		name:    "nacos-deregister " + serverName,
+		phase:   ShutdownPhaseDeregister,
+		timeout: defaultStopTimeout + app.drainDelay,
+		stop: func(ctx context.Context) error {
+			app.draining.Store(true)
+			// 未注册实例时无需注销，只保留排空等待
+			if app.registerInstance {
+				if err := app.nacosNaming.DeregisterServiceInstance(serviceName, ip, port); err != nil {
+					// 即使注销失败，也要继续关闭服务器，但记录错误
+					logger.Logger.Error().Msgf("❌ Error deregistering '%s' from Nacos: %v", serviceName, err)
+				} else {
+					logger.Logger.Printf("✅ Service '%s' deregistered from Nacos.", serviceName)
+				}
+			}
+			if app.drainDelay > 0 {
+				logger.Logger.Printf("Draining '%s' for %s before shutting down HTTP server...", serviceName, app.drainDelay)
+				select {
+				case <-time.After(app.drainDelay):
+				case <-ctx.Done():
+				}
+			}
+			return nil
+		},
+	})
 
-		// 再关闭 HTTP 服务器
-		return app.httpServer.Shutdown(shutdownTimeoutCtx)
+	// 再关闭 HTTP 服务器
+	app.shutdown.add(stopHook{
+		name:    serverName,
+		phase:   ShutdownPhaseServers,
+		timeout: 10 * time.Second,
+		stop: func(ctx context.Context) error {
+			logger.Logger.Printf("Shutting down HTTP server for '%s'...", serviceName)
+			return app.httpServer.Shutdown(ctx)
+		},
 	})
 
 	return nil
 }
 
+// Context 返回应用的生命周期 context，它在应用开始关停时(收到信号、任务失败或调用 Shutdown)被取消。
+// AddTask 的 start 函数收到的也是这个 context。
+func (app *Application) Context() context.Context {
+	return app.shutdownCtx
+}
+
+// Shutdown 以编程方式触发优雅关停，例如业务遇到无法恢复的错误时。
+// 它只发起关停并立即返回，关停钩子按阶段执行完毕后 Run 返回；重复调用是安全的。
+func (app *Application) Shutdown() {
+	if app.shutdownCtx.Err() != nil {
+		return
+	}
+	logger.Logger.Printf("Shutdown requested for '%s', initiating graceful shutdown...", app.serviceName)
+	app.shutdownCancel()
+}
+
+// Connections 返回应用的长连接跟踪器。WebSocket 等长连接建立后应调用 Track 登记，
+// 服务器关停开始时会通过 GracefulCloser 逐个优雅关闭它们:
+//
+//	release, err := app.Connections().Track(wsConn)
+//	if err != nil { // 服务器正在关停，拒绝新连接
+//		return
+//	}
+//	defer release()
+func (app *Application) Connections() *ConnTracker {
+	return app.conns
+}
+
 // AddTask 注册一个通用的后台任务，并管理其生命周期。
 // start: 启动任务的函数。它接收一个上下文，当该上下文被取消时，任务应停止。
 // stop:  （可选）关闭任务的函数，用于释放资源。
-func (app *Application) AddTask(start func(ctx context.Context) error, stop func(ctx context.Context) error) {
+//
+// 关停时 stop 函数按阶段顺序执行(见 ShutdownPhase)，默认位于 ShutdownPhaseTasks:
+// 在 Nacos 注销和 HTTP 服务器关闭之后、Nacos 客户端和 TracerProvider 关闭之前。
+// 建议通过 WithName 为任务命名，任务失败时返回的错误和日志会带上任务名。
+func (app *Application) AddTask(start func(ctx context.Context) error, stop func(ctx context.Context) error, opts ...TaskOption) {
+	app.taskSeq++
+	hook := stopHook{name: fmt.Sprintf("task#%d", app.taskSeq), phase: ShutdownPhaseTasks, timeout: defaultStopTimeout}
+	for _, opt := range opts {
+		opt(&hook)
+	}
+	name := hook.name
+
 	if start != nil {
-		app.g.Go(func() error {
-			return start(app.shutdownCtx)
+		app.goTask(func() error {
+			if err := start(app.shutdownCtx); err != nil {
+				logger.Logger.Error().Err(err).Str("task", name).Msg("❌ Background task failed")
+				return fmt.Errorf("task %s failed: %w", name, err)
+			}
+			return nil
 		})
 	}
 
 	if stop != nil {
-		app.g.Go(func() error {
-			<-app.shutdownCtx.Done() // 等待关停信号
-			logger.Logger.Println("Stopping background task...")
-			// 为关停操作也设置一个超时
-			timeoutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			return stop(timeoutCtx)
-		})
+		hook.stop = func(ctx context.Context) error {
+			logger.Logger.Printf("Stopping background task '%s'...", name)
+			return stop(ctx)
+		}
+		app.shutdown.add(hook)
 	}
 }
 
+// goTask 在 errgroup 中运行一个任务。任务返回错误时触发整个应用的优雅关停，
+// 避免关键任务(例如 Kafka 消费者)退出后留下一个仍注册在 Nacos 中的半死进程。
+func (app *Application) goTask(fn func() error) {
+	app.g.Go(func() error {
+		err := fn()
+		if err != nil && app.shutdownCtx.Err() == nil {
+			logger.Logger.Error().Err(err).Msg("❌ Task failed, initiating graceful shutdown...")
+			app.shutdownCancel()
+		}
+		return err
+	})
+}
+
 // addCoreShutdownTasks 注册核心基础设施组件的关停任务。
+// Nacos 客户端在 ShutdownPhaseInfra 阶段关闭，TracerProvider 在最后的 ShutdownPhaseTracer 阶段关闭。
 func (app *Application) addCoreShutdownTasks() {
 	app.AddTask(nil, func(ctx context.Context) error {
 		logger.Logger.Printf("Closing Nacos clients...")
-		nacosConfigClient.CloseClient()
-		app.nacosNaming.Close()
+		// 文件模式下没有 Nacos 配置客户端
+		if app.nacosConfig != nil {
+			app.nacosConfig.CloseClient()
+		}
+		if app.nacosNaming != nil {
+			app.nacosNaming.Close()
+		}
 		logger.Logger.Printf("✅ Nacos clients closed.")
 		return nil
-	})
+	}, WithName("nacos-clients"), WithShutdownPhase(ShutdownPhaseInfra))
 	app.AddTask(nil, func(ctx context.Context) error {
 		logger.Logger.Printf("Shutting down tracer provider...")
 		if err := app.tracer.Shutdown(ctx); err != nil {
@@ -201,7 +371,20 @@ func (app *Application) addCoreShutdownTasks() {
 		}
 		logger.Logger.Printf("✅ Tracer provider shut down.")
 		return nil
-	})
+	}, WithName("tracer-provider"), WithShutdownPhase(ShutdownPhaseTracer))
+}
+
+// abort 在 NewApplication 失败时释放已经创建的资源:
+// 取消已启动的任务，并执行已注册的关停钩子(包括 Nacos 注销、HTTP 服务器、Nacos 客户端和 TracerProvider)。
+func (app *Application) abort() {
+	app.addCoreShutdownTasks()
+	app.shutdownCancel()
+	if err := app.shutdown.run(); err != nil {
+		logger.Logger.Error().Err(err).Msg("❌ Failed to release resources after startup failure")
+	}
+	if err := app.g.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+		logger.Logger.Error().Err(err).Msg("❌ Task failed while aborting startup")
+	}
 }
 
 // Run 启动整个应用，并阻塞等待关停信号。
@@ -209,16 +392,30 @@ func (app *Application) Run() error {
 	// 启动一个 goroutine 来监听操作系统的中断信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	// SIGHUP 用于在文件模式下重新加载配置，见 reloadConfigFromFile
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
 
 	app.g.Go(func() error {
-		select {
-		case <-app.shutdownCtx.Done():
-			return nil // 由其他任务触发的关停
-		case sig := <-quit:
-			logger.Logger.Printf("Received signal '%v', initiating graceful shutdown...", sig)
-			app.shutdownCancel() // 触发所有任务的关停
+		for {
+			select {
+			case <-app.shutdownCtx.Done():
+				return nil // 由其他任务触发的关停
+			case <-hup:
+				reloadConfigFromFile()
+			case sig := <-quit:
+				logger.Logger.Printf("Received signal '%v', initiating graceful shutdown...", sig)
+				app.shutdownCancel() // 触发所有任务的关停
+				return nil
+			}
 		}
-		return nil
+	})
+
+	// 收到关停信号后按阶段依次执行所有关停钩子
+	app.g.Go(func() error {
+		<-app.shutdownCtx.Done()
+		return app.shutdown.run()
 	})
 
 	serviceName := app.serviceName
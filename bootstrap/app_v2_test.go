@@ -0,0 +1,114 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// shutdownRecorder 记录 TracerProvider 是否被关闭
+type shutdownRecorder struct {
+	shutdown atomic.Bool
+}
+
+func (r *shutdownRecorder) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+func (r *shutdownRecorder) OnEnd(sdktrace.ReadOnlySpan)                     {}
+func (r *shutdownRecorder) ForceFlush(context.Context) error                { return nil }
+func (r *shutdownRecorder) Shutdown(context.Context) error {
+	r.shutdown.Store(true)
+	return nil
+}
+
+// waitForGoroutines 等待 goroutine 数量回落到 n 以内
+func waitForGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines leaked: %d running, want <= %d", runtime.NumGoroutine(), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewApplicationAssembleErrorReleasesResources(t *testing.T) {
+	before := runtime.NumGoroutine()
+	rec := &shutdownRecorder{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	assembleErr := errors.New("database unreachable")
+	workerDone := make(chan struct{})
+	// 文件模式下没有 Nacos 客户端，abort 不应因此 panic
+	app, err := newApplication(AppInfoV2[struct{}]{
+		ServiceName: "test-service",
+		Assemble: func(appCtx AppContext) (struct{}, error) {
+			// 模拟组装过程中已经启动的后台 goroutine
+			go func() {
+				<-appCtx.Context.Done()
+				close(workerDone)
+			}()
+			return struct{}{}, assembleErr
+		},
+		Register: func(*Application, struct{}) error {
+			t.Error("Register must not be called after Assemble fails")
+			return nil
+		},
+	}, tp, nil)
+
+	if app != nil {
+		t.Errorf("app = %v, want nil", app)
+	}
+	if !errors.Is(err, assembleErr) {
+		t.Fatalf("err = %v, want it to wrap %v", err, assembleErr)
+	}
+	if !rec.shutdown.Load() {
+		t.Error("tracer provider was not shut down")
+	}
+	select {
+	case <-workerDone:
+	case <-time.After(time.Second):
+		t.Fatal("application context was not cancelled")
+	}
+	waitForGoroutines(t, before)
+}
+
+func TestNewApplicationRegisterErrorStopsTasks(t *testing.T) {
+	before := runtime.NumGoroutine()
+	rec := &shutdownRecorder{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	registerErr := errors.New("port already in use")
+	var stopped atomic.Bool
+	_, err := newApplication(AppInfoV2[struct{}]{
+		ServiceName: "test-service",
+		Assemble: func(AppContext) (struct{}, error) {
+			return struct{}{}, nil
+		},
+		Register: func(app *Application, _ struct{}) error {
+			app.AddTask(func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}, func(context.Context) error {
+				stopped.Store(true)
+				return nil
+			}, WithName("consumer"))
+			return registerErr
+		},
+	}, tp, nil)
+
+	if !errors.Is(err, registerErr) {
+		t.Fatalf("err = %v, want it to wrap %v", err, registerErr)
+	}
+	if !stopped.Load() {
+		t.Error("stop hook of the registered task was not called")
+	}
+	if !rec.shutdown.Load() {
+		t.Error("tracer provider was not shut down")
+	}
+	waitForGoroutines(t, before)
+}
@@ -1,35 +1,63 @@
 package bootstrap
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/nacos-group/nacos-sdk-go/v2/clients"
 	"github.com/nacos-group/nacos-sdk-go/v2/clients/config_client"
 	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
 	"github.com/nacos-group/nacos-sdk-go/v2/vo"
 	"github.com/wangyingjie930/nexus-pkg/logger"
+	"github.com/wangyingjie930/nexus-pkg/utils"
 	"gopkg.in/yaml.v3"
 )
 
+// InfraConfig 存放基础设施配置。带有 `env` 标签的字段可以被同名环境变量覆盖。
 type InfraConfig struct {
 	Kafka struct {
-		Brokers string `yaml:"brokers"`
+		Brokers string `yaml:"brokers" env:"NEXUS_KAFKA_BROKERS"`
+		// SASL 认证配置，Mechanism 为空时不认证
+		SASL struct {
+			// Mechanism 取值 plain、scram-sha-256、scram-sha-512
+			Mechanism string `yaml:"mechanism" env:"NEXUS_KAFKA_SASL_MECHANISM"`
+			Username  string `yaml:"username" env:"NEXUS_KAFKA_SASL_USERNAME"`
+			Password  string `yaml:"password" env:"NEXUS_KAFKA_SASL_PASSWORD" secret:"true"`
+		} `yaml:"sasl"`
+		TLS struct {
+			Enabled bool `yaml:"enabled" env:"NEXUS_KAFKA_TLS_ENABLED"`
+			// CAFile 为自定义 CA 证书路径，为空时使用系统根证书
+			CAFile             string `yaml:"caFile" env:"NEXUS_KAFKA_TLS_CA_FILE"`
+			InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+		} `yaml:"tls"`
 	} `yaml:"kafka"`
 	Redis struct {
-		Addrs string `yaml:"addrs"`
+		Addrs string `yaml:"addrs" env:"NEXUS_REDIS_ADDRS"`
 	} `yaml:"redis"`
 	Jaeger struct {
-		Endpoint string `yaml:"endpoint"`
+		Endpoint string `yaml:"endpoint" env:"NEXUS_JAEGER_ENDPOINT"`
+		// SampleRatio 为链路采样比例，取值 (0, 1]；为 0 时保持全量采样
+		SampleRatio float64 `yaml:"sampleRatio" env:"NEXUS_JAEGER_SAMPLE_RATIO"`
+		// Disabled 为 true 时不连接 Jaeger，Span 照常生成与传播但不导出，适用于本地开发
+		Disabled bool `yaml:"disabled" env:"NEXUS_JAEGER_DISABLED"`
+		// 批处理导出参数，为 0 时使用默认值(5s / 512 / 2048)
+		BatchTimeout       time.Duration `yaml:"batchTimeout" env:"NEXUS_JAEGER_BATCH_TIMEOUT"`
+		MaxExportBatchSize int           `yaml:"maxExportBatchSize" env:"NEXUS_JAEGER_MAX_EXPORT_BATCH_SIZE"`
+		MaxQueueSize       int           `yaml:"maxQueueSize" env:"NEXUS_JAEGER_MAX_QUEUE_SIZE"`
 	} `yaml:"jaeger"`
 	Zookeeper struct {
-		Addrs string `yaml:"addrs"`
+		Addrs string `yaml:"addrs" env:"NEXUS_ZOOKEEPER_ADDRS"`
 	} `yaml:"zookeeper"`
 	Mysql struct {
-		Addrs string `yaml:"addrs"`
+		Addrs string `yaml:"addrs" env:"NEXUS_MYSQL_ADDRS"`
 	}
 }
 
@@ -39,13 +67,54 @@ type AppConfig struct {
 		ProcessingTimeoutSeconds int `yaml:"processingTimeoutSeconds"`
 		PaymentTimeoutSeconds    int `yaml:"paymentTimeoutSeconds"`
 	} `yaml:"orderService"`
-	FeatureFlags struct {
-		EnableVipPromotion bool `yaml:"enableVipPromotion"`
-	} `yaml:"featureFlags"`
+	FeatureFlags FeatureFlags `yaml:"featureFlags"`
 
 	Resilience ResilienceConfig `yaml:"resilience"`
 }
 
+// FeatureFlags 是 app.featureFlags 配置段。除了有类型的开关，段中的其他键都会进入 Flags，
+// 新增开关只需修改配置，代码通过 Config.IsEnabled 按名称查询，无需修改结构体。
+// 有类型的开关必须是 bool，并通过 yaml 标签中的名称查询。
+type FeatureFlags struct {
+	EnableVipPromotion bool `yaml:"enableVipPromotion"`
+	// Flags 收集 featureFlags 段中除上述字段以外的所有键。
+	// 使用 any 是为了容忍写错的值: 非布尔值不会导致整个配置解析失败，查询时视为未配置。
+	Flags map[string]any `yaml:",inline"`
+}
+
+// IsEnabled 按名称查询开关，未配置的开关视为关闭
+func (f FeatureFlags) IsEnabled(flag string) bool {
+	return f.EnabledOr(flag, false)
+}
+
+// EnabledOr 按名称查询开关，未配置时返回 def
+func (f FeatureFlags) EnabledOr(flag string, def bool) bool {
+	// 有类型的开关同样可以按名称查询
+	if enabled, ok := f.typedFlag(flag); ok {
+		return enabled
+	}
+	if enabled, ok := f.Flags[flag].(bool); ok {
+		return enabled
+	}
+	return def
+}
+
+// typedFlag 按 yaml 标签中的名称查找有类型的布尔开关
+func (f FeatureFlags) typedFlag(flag string) (enabled, ok bool) {
+	v := reflect.ValueOf(f)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() != reflect.Bool {
+			continue
+		}
+		if name, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); name == flag {
+			return v.Field(i).Bool(), true
+		}
+	}
+	return false, false
+}
+
 // ResilienceConfig 结构体
 type ResilienceConfig struct {
 	Consumers map[string]ConsumerResilienceConfig `yaml:"consumers"`
@@ -58,6 +127,7 @@ type ConsumerResilienceConfig struct {
 	RetryTopicTemplate  string   `yaml:"retryTopicTemplate"`
 	DltTopicTemplate    string   `yaml:"dltTopicTemplate"`
 	RetryableExceptions []string `yaml:"retryableExceptions"`
+	MaxHeaderBytes      int      `yaml:"maxHeaderBytes"` // 异常信息 Header 的长度上限，<= 0 时使用默认值
 }
 
 // CombinedConfig 是一个临时结构体，用于从单个文件中加载所有配置
@@ -74,28 +144,106 @@ type Config struct {
 
 var (
 	// 全局配置实例
+	//
+	// Deprecated: 直接读取 GlobalConfig 会与热更新产生数据竞争，
+	// 请使用 GetCurrentConfig、GetInfra 或 GetApp 读取当前配置快照。
 	GlobalConfig = new(Config)
-	// 用于保护全局配置的读写
-	configLock = new(sync.RWMutex)
+	// 当前生效的配置快照。快照一经发布便不再修改，更新时整体替换。
+	currentConfig atomic.Pointer[Config]
+	// 用于串行化配置的写入(文件加载与 Nacos 热更新)
+	configLock = new(sync.Mutex)
 	// Nacos 配置客户端，在Init中创建，在StartService的优雅关停中关闭
 	nacosConfigClient config_client.IConfigClient
 
 	nacosServerAddrs string
 	nacosNamespace   string
 	nacosGroup       string
+
+	// 当前生效的加载选项，在 Load 中设置
+	loadOptions LoadOptions
+)
+
+// LoadOptions 控制配置的加载行为
+type LoadOptions struct {
+	// OnConfigChange 在每次配置热更新成功应用之后被调用，dataId 为发生变化的 Nacos DataId；
+	// 文件模式下收到 SIGHUP 重新加载时，dataId 为 NEXUS_CONFIG_PATH 的值。
+	// 回调运行在 Nacos SDK 的监听 goroutine(或信号处理 goroutine)中，耗时的重建逻辑建议配合 utils.Debounce 使用。
+	OnConfigChange func(dataId string)
+	// Bindings 指定需要从 Nacos 拉取并监听的配置文档。
+	// 为空时使用默认的 nexus-infra.yaml 与 nexus-app.yaml 两个文档。
+	Bindings []ConfigBinding
+	// FetchAttempts 为启动时拉取每个 Nacos 配置文档的最大尝试次数，默认 5 次
+	FetchAttempts int
+	// FetchBackoff 为首次重试前的等待时间，之后每次翻倍，最长 30 秒，默认 1 秒
+	FetchBackoff time.Duration
+	// CacheFallback 为 true 时，重试耗尽后尝试使用 Nacos SDK 本地缓存目录中上一次成功拉取的配置启动
+	CacheFallback bool
+}
+
+const (
+	defaultFetchAttempts = 5
+	defaultFetchBackoff  = time.Second
+	maxFetchBackoff      = 30 * time.Second
+
+	defaultNacosLogDir   = "/tmp/nacos/log"
+	defaultNacosCacheDir = "/tmp/nacos/cache"
+	defaultNacosLogLevel = "warn"
 )
 
-// Init 是应用启动的第一步，负责加载所有配置。
-// 它支持优先从本地文件加载(通过 NEXUS_CONFIG_PATH 环境变量),
-// 如果文件路径未提供，则回退到 Nacos。
+// ConfigBinding 将一个 Nacos 配置文档绑定到一个解析目标
+type ConfigBinding struct {
+	DataId string
+	// Group 为空时使用 NACOS_GROUP 环境变量指定的分组
+	Group string
+	// Target 返回配置文档的解析目标(必须是指针)。它接收正在构建的新配置快照，
+	// 通常返回其中某个字段的地址。也可以返回业务自己的配置结构体的地址，
+	// 但这样的目标会被原地更新，不受快照机制保护，需要业务自行同步。
+	Target func(cfg *Config) interface{}
+}
+
+// defaultBindings 返回默认的两个配置文档绑定
+func defaultBindings() []ConfigBinding {
+	return []ConfigBinding{
+		// a. 基础设施配置
+		{DataId: "nexus-infra.yaml", Target: func(cfg *Config) interface{} { return &cfg.Infra }},
+		// b. 应用业务配置
+		{DataId: "nexus-app.yaml", Target: func(cfg *Config) interface{} { return &cfg.App }},
+	}
+}
+
+// Init 是应用启动的第一步，负责加载并校验所有配置。
+// 它支持优先从本地文件加载(通过 NEXUS_CONFIG_PATH 环境变量，可以是单个文件或包含多个 *.yaml 文件的目录),
+// 如果文件路径未提供，则回退到 Nacos。配置校验失败时直接终止启动。
 func Init() {
-	logger.Init("bootstrap")
+	Load(LoadOptions{})
+}
 
+// Load 与 Init 相同，但允许通过 LoadOptions 定制加载行为
+func Load(opts LoadOptions) {
+	loadOptions = opts
+
+	// 仅在 Logger 尚未初始化时使用引导期的临时服务名，避免覆盖已经初始化好的 Logger
+	logger.InitOnce("bootstrap")
+
+	loadConfig()
+
+	// 加载完成后立即校验，避免带着非法的关键配置启动后才莫名失败
+	var bindings []ConfigBinding
+	if GetConfigStatus().Source == ConfigSourceNacos {
+		bindings = nacosBindings()
+	}
+	if err := validateLoaded(snapshot(), bindings); err != nil {
+		logger.Logger.Fatal().Err(err).Msg("FATAL: Configuration validation failed")
+	}
+}
+
+// loadConfig 优先从本地文件加载配置，失败时回退到 Nacos
+func loadConfig() {
 	// 优先尝试从本地文件加载
 	configPath := getEnv("NEXUS_CONFIG_PATH", "")
 	if configPath != "" {
 		logger.Logger.Info().Msgf("Attempting to load configuration from file: %s", configPath)
-		if err := loadConfigFromFile(configPath); err == nil {
+		if err := loadConfigFromFile(configPath, false); err == nil {
 			logger.Logger.Info().Msg("✅ Configuration loaded successfully from file.")
 			return // 从文件成功加载，跳过 Nacos
 		} else {
@@ -108,30 +256,105 @@ func Init() {
 	initFromNacos()
 }
 
-// loadConfigFromFile 从单个 YAML 文件加载整个配置。
+// loadConfigFromFile 从本地 YAML 文件加载整个配置。
 // 这对于本地开发或没有 Nacos 的环境非常有用。
-func loadConfigFromFile(filePath string) error {
-	content, err := os.ReadFile(filePath)
+// filePath 为目录时，按文件名顺序加载其中所有 *.yaml 文件并合并，后加载的文件按键覆盖先加载的，
+// 例如把配置拆分为 infra.yaml、app.yaml 以及各功能自己的文件。
+// validate 为 true 时先校验新配置，校验失败则返回错误并保留当前配置；启动时由 Load 统一校验。
+func loadConfigFromFile(filePath string, validate bool) error {
+	files, err := configFiles(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to read config file %s: %w", filePath, err)
+		return err
 	}
 
 	configLock.Lock()
 	defer configLock.Unlock()
 
 	var combinedConfig CombinedConfig
-	if err := yaml.Unmarshal(content, &combinedConfig); err != nil {
-		return fmt.Errorf("failed to unmarshal config file: %w", err)
+	if err := decodeConfigFiles(files, &combinedConfig); err != nil {
+		return err
 	}
 
-	// 从组合结构体填充全局配置
-	GlobalConfig.Infra = combinedConfig.Infra
-	GlobalConfig.App = combinedConfig.App
+	// 环境变量的优先级高于文件内容
+	if err := applyEnvOverrides(&combinedConfig); err != nil {
+		return err
+	}
+
+	// 从组合结构体构建新的配置快照
+	next := &Config{Infra: combinedConfig.Infra, App: combinedConfig.App}
+	if validate {
+		if err := next.Validate(); err != nil {
+			return err
+		}
+	}
+	publishConfig(next)
+	recordConfigReload(ConfigSourceFile, filePath)
 
-	logger.Logger.Info().Any("GlobalConfig", GlobalConfig).Msg("✅ Bootstrap: Configuration loaded from file.")
+	logger.Logger.Info().Any("GlobalConfig", next).Strs("files", files).Msg("✅ Bootstrap: Configuration loaded from file.")
 	return nil
 }
 
+// reloadConfigFromFile 在文件模式下重新加载 NEXUS_CONFIG_PATH，由 SIGHUP 触发。
+// 与 Nacos 热更新走相同的路径: 在 configLock 下校验并发布新的快照，然后调用 OnConfigChange；
+// 加载或校验失败时保留当前配置，也不触发回调。配置来自 Nacos 时什么也不做。
+func reloadConfigFromFile() {
+	configPath := getEnv("NEXUS_CONFIG_PATH", "")
+	if configPath == "" || GetConfigStatus().Source != ConfigSourceFile {
+		logger.Logger.Info().Msg("ℹ️ Received SIGHUP but configuration is not loaded from file, ignoring")
+		return
+	}
+	logger.Logger.Printf("🔔 Received SIGHUP, reloading configuration from file: %s", configPath)
+	if err := loadConfigFromFile(configPath, true); err != nil {
+		logger.Logger.Error().Err(err).Msg("❌ Failed to reload configuration from file, keeping the current configuration")
+		return
+	}
+	if loadOptions.OnConfigChange != nil {
+		loadOptions.OnConfigChange(configPath)
+	}
+}
+
+// decodeConfigFiles 依次将 files 解码到同一个 out 中，后面文件中出现的键覆盖前面的值，未出现的键保持不变
+func decodeConfigFiles(files []string, out interface{}) error {
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read config file %s: %w", file, err)
+		}
+		if err := yaml.Unmarshal(content, out); err != nil {
+			return fmt.Errorf("failed to unmarshal config file %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// configFiles 返回需要加载的配置文件: path 为文件时返回它本身，为目录时返回其中按文件名排序的 *.yaml 文件
+func configFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config path %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(path, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list config directory %s: %w", path, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no *.yaml files found in config directory %s", path)
+	}
+	// filepath.Glob 的结果已按文件名排序
+	return files, nil
+}
+
+// nacosBindings 返回需要从 Nacos 拉取并监听的配置文档绑定
+func nacosBindings() []ConfigBinding {
+	if len(loadOptions.Bindings) == 0 {
+		return defaultBindings()
+	}
+	return loadOptions.Bindings
+}
+
 // initFromNacos 从 Nacos 初始化配置。
 func initFromNacos() {
 	// 1. 获取最基础的引导配置 (Nacos地址)
@@ -157,37 +380,84 @@ func initFromNacos() {
 		logger.Logger.Fatal().Msgf("FATAL: Failed to create Nacos config client: %v", err)
 	}
 
-	// 4. 拉取并监听两个配置文件
-	// a. 基础设施配置
-	initAndWatchSingleConfig("nexus-infra.yaml", nacosGroup, &GlobalConfig.Infra)
-	// b. 应用业务配置
-	initAndWatchSingleConfig("nexus-app.yaml", nacosGroup, &GlobalConfig.App)
+	// 4. 拉取并监听所有绑定的配置文档
+	for _, b := range nacosBindings() {
+		group := b.Group
+		if group == "" {
+			group = nacosGroup
+		}
+		initAndWatchSingleConfig(b, group)
+	}
 
-	logger.Logger.Info().Any("GlobalConfig", GlobalConfig).Msg("✅ Bootstrap Phase 1: All configurations loaded and watched successfully from Nacos.")
+	logger.Logger.Info().Any("GlobalConfig", currentConfig.Load()).Msg("✅ Bootstrap Phase 1: All configurations loaded and watched successfully from Nacos.")
 }
 
-// GetCurrentConfig 返回一个线程安全的配置副本
+// GetCurrentConfig 返回当前配置快照的副本，读取不加锁。
+// 快照在发布后不会被修改，其中的切片和 map 可以安全地并发读取，但调用方不应修改它们。
 func GetCurrentConfig() Config {
-	configLock.RLock()
-	defer configLock.RUnlock()
-	return *GlobalConfig
+	return *snapshot()
+}
+
+// IsEnabled 按名称查询 app.featureFlags 中的开关，未配置的开关视为关闭
+func (c Config) IsEnabled(flag string) bool {
+	return c.App.FeatureFlags.IsEnabled(flag)
+}
+
+// IsFeatureEnabled 在当前配置快照中按名称查询开关，配置热更新后立即生效
+func IsFeatureEnabled(flag string) bool {
+	return snapshot().IsEnabled(flag)
+}
+
+// GetInfra 返回当前快照中的基础设施配置
+func GetInfra() InfraConfig {
+	return snapshot().Infra
+}
+
+// GetApp 返回当前快照中的业务配置
+func GetApp() AppConfig {
+	return snapshot().App
+}
+
+// snapshot 返回当前配置快照，尚未加载时返回空配置
+func snapshot() *Config {
+	if cfg := currentConfig.Load(); cfg != nil {
+		return cfg
+	}
+	return &Config{}
+}
+
+// publishConfig 原子地发布新的配置快照，调用方需持有 configLock
+func publishConfig(next *Config) {
+	currentConfig.Store(next)
+	// 兼容仍在读取 GlobalConfig 的旧代码
+	*GlobalConfig = *next
 }
 
 // initAndWatchSingleConfig 是一个通用函数，用于拉取、解析和监听单个配置文件
-func initAndWatchSingleConfig(dataId, group string, configPtr interface{}) {
-	content, err := nacosConfigClient.GetConfig(vo.ConfigParam{DataId: dataId, Group: group})
+func initAndWatchSingleConfig(binding ConfigBinding, group string) {
+	dataId := binding.DataId
+	content, err := fetchInitialConfig(dataId, group)
 	if err != nil {
 		logger.Logger.Fatal().Msgf("FATAL: Failed to get initial config for DataId '%s': %v", dataId, err)
 	}
 
-	updateConfig(content, configPtr) // 加载初始配置
+	// 加载初始配置，非法的初始配置直接终止启动
+	if !updateConfig(binding, content) {
+		logger.Logger.Fatal().Msgf("FATAL: Failed to apply initial config for DataId '%s'", dataId)
+	}
 
 	err = nacosConfigClient.ListenConfig(vo.ConfigParam{
 		DataId: dataId,
 		Group:  group,
 		OnChange: func(_, _, _, data string) {
 			logger.Logger.Printf("🔔 Nacos config changed for DataId: %s. Applying new config...", dataId)
-			updateConfig(data, configPtr)
+			// 解析或校验失败时保留旧配置，也不触发回调
+			if !updateConfig(binding, data) {
+				return
+			}
+			if loadOptions.OnConfigChange != nil {
+				loadOptions.OnConfigChange(dataId)
+			}
 		},
 	})
 	if err != nil {
@@ -195,13 +465,97 @@ func initAndWatchSingleConfig(dataId, group string, configPtr interface{}) {
 	}
 }
 
-// updateConfig 线程安全地更新配置
-func updateConfig(content string, configPtr interface{}) {
+// fetchInitialConfig 带退避重试地拉取配置文档，重试耗尽后按需回退到本地缓存
+func fetchInitialConfig(dataId, group string) (string, error) {
+	attempts := loadOptions.FetchAttempts
+	if attempts <= 0 {
+		attempts = defaultFetchAttempts
+	}
+	backoff := loadOptions.FetchBackoff
+	if backoff <= 0 {
+		backoff = defaultFetchBackoff
+	}
+
+	var content string
+	attempt := 0
+	policy := utils.RetryPolicy{MaxAttempts: attempts, BaseDelay: backoff, MaxDelay: maxFetchBackoff, Multiplier: 2}
+	lastErr := utils.Retry(context.Background(), policy, func() error {
+		attempt++
+		var err error
+		content, err = nacosConfigClient.GetConfig(vo.ConfigParam{DataId: dataId, Group: group})
+		if err != nil && attempt < attempts {
+			logger.Logger.Warn().Err(err).Int("attempt", attempt).
+				Msgf("⚠️ Failed to get config for DataId '%s', retrying...", dataId)
+		}
+		return err
+	})
+	if lastErr == nil {
+		return content, nil
+	}
+
+	if loadOptions.CacheFallback {
+		content, err := readCachedConfig(dataId, group)
+		if err == nil {
+			logger.Logger.Warn().Err(lastErr).Msgf("⚠️ Nacos unavailable, starting with cached config for DataId '%s'", dataId)
+			return content, nil
+		}
+		logger.Logger.Warn().Err(err).Msgf("⚠️ No usable cached config for DataId '%s'", dataId)
+	}
+	return "", fmt.Errorf("giving up after %d attempts: %w", attempts, lastErr)
+}
+
+// readCachedConfig 读取 Nacos SDK 在本地缓存目录中保存的配置快照
+func readCachedConfig(dataId, group string) (string, error) {
+	// 与 SDK 的缓存文件命名保持一致: {cacheDir}/config/{dataId}@@{group}@@{namespace}
+	path := filepath.Join(nacosCacheDir(), "config", dataId+"@@"+group+"@@"+nacosNamespace)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config cache %s: %w", path, err)
+	}
+	if len(content) == 0 {
+		return "", fmt.Errorf("config cache %s is empty", path)
+	}
+	return string(content), nil
+}
+
+// updateConfig 将配置文档解析到当前快照的一个副本中，校验通过后原子地替换快照，返回是否成功应用。
+// 解析或校验失败时旧快照保持不变，读取方永远不会看到更新到一半或非法的配置。
+func updateConfig(binding ConfigBinding, content string) bool {
+	dataId := binding.DataId
 	configLock.Lock()
 	defer configLock.Unlock()
-	if err := yaml.Unmarshal([]byte(content), configPtr); err != nil {
+
+	next := new(Config)
+	*next = *snapshot()
+	target := reflect.ValueOf(binding.Target(next))
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		logger.Logger.Printf("❌ ERROR: Target of DataId '%s' must be a non-nil pointer", dataId)
+		return false
+	}
+	// 解析到一个全新的值中: 副本与旧快照共享切片和 map，直接解析到目标会修改旧快照
+	fresh := reflect.New(target.Elem().Type())
+	if err := yaml.Unmarshal([]byte(content), fresh.Interface()); err != nil {
 		logger.Logger.Printf("❌ ERROR: Failed to unmarshal Nacos config: %v", err)
+		return false
+	}
+	// 环境变量的优先级高于 Nacos 下发的内容
+	if err := applyEnvOverrides(fresh.Interface()); err != nil {
+		logger.Logger.Printf("❌ ERROR: Failed to apply env overrides for DataId '%s': %v", dataId, err)
+		return false
+	}
+
+	// 目标也可能是快照之外的业务配置结构体，校验失败时需要恢复它的旧值
+	previous := reflect.New(target.Elem().Type()).Elem()
+	previous.Set(target.Elem())
+	target.Elem().Set(fresh.Elem())
+	if err := validateLoaded(next, []ConfigBinding{binding}); err != nil {
+		target.Elem().Set(previous)
+		logger.Logger.Error().Err(err).Msgf("❌ Config for DataId '%s' failed validation, keeping the previous configuration", dataId)
+		return false
 	}
+	publishConfig(next)
+	recordConfigReload(ConfigSourceNacos, dataId)
+	return true
 }
 
 // ✨ 新增: Nacos ServerConfig 工厂函数
@@ -222,17 +576,24 @@ func createNacosServerConfigs(addrs string) ([]constant.ServerConfig, error) {
 }
 
 // ✨ 新增: Nacos ClientConfig 工厂函数
+// 日志目录、缓存目录与日志级别可以分别通过 NACOS_LOG_DIR、NACOS_CACHE_DIR、NACOS_LOG_LEVEL 环境变量覆盖，
+// 用于只读根文件系统的容器，或同一主机上需要相互隔离缓存的多个进程。
 func createNacosClientConfig(namespaceId string) constant.ClientConfig {
 	return *constant.NewClientConfig(
 		constant.WithNamespaceId(namespaceId),
 		constant.WithTimeoutMs(5000),
 		constant.WithNotLoadCacheAtStart(true),
-		constant.WithLogDir("/tmp/nacos/log"),
-		constant.WithCacheDir("/tmp/nacos/cache"),
-		constant.WithLogLevel("warn"),
+		constant.WithLogDir(getEnv("NACOS_LOG_DIR", defaultNacosLogDir)),
+		constant.WithCacheDir(nacosCacheDir()),
+		constant.WithLogLevel(getEnv("NACOS_LOG_LEVEL", defaultNacosLogLevel)),
 	)
 }
 
+// nacosCacheDir 返回 Nacos SDK 的本地缓存目录
+func nacosCacheDir() string {
+	return getEnv("NACOS_CACHE_DIR", defaultNacosCacheDir)
+}
+
 // getEnv 是一个内部辅助函数，从环境变量中读取配置。
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
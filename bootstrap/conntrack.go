@@ -0,0 +1,97 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+// ErrServerClosing 表示服务器已经开始关停，不再接受新的长连接
+var ErrServerClosing = errors.New("bootstrap: server is shutting down")
+
+// GracefulCloser 是需要在关停时被优雅关闭的长连接，例如 WebSocket 连接。
+// 实现方应发送关闭帧(例如 WebSocket close 1001 Going Away)，通知客户端重连到其他节点，
+// 并完成会话清理(例如调用 session.Manager.ClearUserGateway)，使用户可以平滑迁移。
+type GracefulCloser interface {
+	GracefulClose(ctx context.Context) error
+}
+
+// ConnTracker 跟踪被劫持(Hijack)的长连接。
+// http.Server.Shutdown 不会关闭被劫持的连接，需要由 ConnTracker 在关停开始时逐个优雅关闭。
+type ConnTracker struct {
+	mu      sync.Mutex
+	conns   map[*trackedConn]struct{}
+	closing bool
+}
+
+type trackedConn struct {
+	closer GracefulCloser
+}
+
+// NewConnTracker 创建一个空的 ConnTracker
+func NewConnTracker() *ConnTracker {
+	return &ConnTracker{conns: make(map[*trackedConn]struct{})}
+}
+
+// Track 开始跟踪一个长连接，连接正常断开时应调用返回的 release。
+// 关停已经开始时返回 ErrServerClosing，调用方应拒绝该连接。
+func (t *ConnTracker) Track(c GracefulCloser) (release func(), err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing {
+		return nil, ErrServerClosing
+	}
+	tc := &trackedConn{closer: c}
+	t.conns[tc] = struct{}{}
+	return func() {
+		t.mu.Lock()
+		delete(t.conns, tc)
+		t.mu.Unlock()
+	}, nil
+}
+
+// Len 返回当前跟踪的连接数
+func (t *ConnTracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.conns)
+}
+
+// CloseAll 停止接受新的连接，并发地优雅关闭所有已跟踪的连接，等待全部完成或 ctx 结束
+func (t *ConnTracker) CloseAll(ctx context.Context) error {
+	t.mu.Lock()
+	t.closing = true
+	conns := make([]*trackedConn, 0, len(t.conns))
+	for tc := range t.conns {
+		conns = append(conns, tc)
+	}
+	t.mu.Unlock()
+
+	if len(conns) == 0 {
+		return nil
+	}
+	logger.Logger.Printf("Closing %d long-lived connections...", len(conns))
+
+	errs := make([]error, len(conns))
+	var wg sync.WaitGroup
+	for i, tc := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = tc.closer.GracefulClose(ctx)
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return errors.Join(errs...)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,126 @@
+package bootstrap
+
+import (
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue 用于替换敏感字段的值
+const redactedValue = "******"
+
+// DumpEffectiveConfig 将当前生效的配置以 YAML 格式写入 w，用于回答"现在到底生效的是什么配置"。
+// 带有 `secret:"true"` 标签的字段会被脱敏，字段名沿用 yaml 标签，因此输出可以直接与配置文件对照。
+func DumpEffectiveConfig(w io.Writer, cfg interface{}) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(redact(reflect.ValueOf(cfg))); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// EffectiveConfigHandler 返回一个输出当前生效配置(已脱敏)的 HTTP Handler，
+// 它不会被自动挂载，需要由业务方挂载到受保护的管理端口上。
+func EffectiveConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		cfg := GetCurrentConfig()
+		if err := DumpEffectiveConfig(w, &cfg); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// redact 将配置转换为通用的 map/slice 结构，并对敏感字段脱敏
+func redact(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redact(v.Elem())
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, inline, skip := yamlFieldName(field)
+			if skip {
+				continue
+			}
+			fv := v.Field(i)
+			if field.Tag.Get("secret") == "true" {
+				if !fv.IsZero() {
+					out[name] = redactedValue
+				} else {
+					out[name] = ""
+				}
+				continue
+			}
+			value := redact(fv)
+			if nested, ok := value.(map[string]interface{}); ok && inline {
+				for k, nv := range nested {
+					out[k] = nv
+				}
+				continue
+			}
+			out[name] = value
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[toKey(iter.Key())] = redact(iter.Value())
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		out := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out[i] = redact(v.Index(i))
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// yamlFieldName 按照 yaml.v3 的规则解析字段名
+func yamlFieldName(field reflect.StructField) (name string, inline, skip bool) {
+	tag := field.Tag.Get("yaml")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			inline = true
+		}
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, inline, false
+}
+
+// toKey 将 map 的键转换为字符串
+func toKey(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	out, _ := yaml.Marshal(k.Interface())
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,199 @@
+package bootstrap
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dumpTestSettings 模拟带有敏感字段的配置结构
+type dumpTestSettings struct {
+	Database struct {
+		DSN      string `yaml:"dsn"`
+		Password string `yaml:"password" secret:"true"`
+	} `yaml:"database"`
+	Common struct {
+		Region string `yaml:"region"`
+	} `yaml:",inline"`
+	Token  string `yaml:"token" secret:"true"`
+	Ignore string `yaml:"-"`
+}
+
+const dumpTestFile = `
+database:
+  dsn: mysql://file-host/orders
+  password: file-password
+region: cn-east
+token: ""
+`
+
+const dumpTestConfig = `
+infra:
+  kafka:
+    brokers: file-broker:9092
+    sasl:
+      mechanism: plain
+      username: nexus
+      password: file-password
+  redis:
+    addrs: redis:6379
+app:
+  featureFlags:
+    enableVipPromotion: true
+`
+
+// writeConfigFile 将 content 写入临时目录中的配置文件并返回其路径
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+// restoreConfig 在测试结束后恢复全局配置快照
+func restoreConfig(t *testing.T) {
+	t.Helper()
+	previous := currentConfig.Load()
+	t.Cleanup(func() {
+		configLock.Lock()
+		defer configLock.Unlock()
+		if previous == nil {
+			previous = &Config{}
+		}
+		publishConfig(previous)
+	})
+}
+
+func TestDumpEffectiveConfigReflectsMergedValuesAndRedactsSecrets(t *testing.T) {
+	var cfg dumpTestSettings
+	if err := yaml.Unmarshal([]byte(dumpTestFile), &cfg); err != nil {
+		t.Fatalf("failed to parse test config: %v", err)
+	}
+	// 模拟环境变量覆盖文件中的值: 导出的是合并之后的配置，而不是文件内容
+	cfg.Database.DSN = "mysql://env-host/orders"
+	cfg.Ignore = "not-dumped"
+
+	var buf bytes.Buffer
+	if err := DumpEffectiveConfig(&buf, &cfg); err != nil {
+		t.Fatalf("DumpEffectiveConfig returned error: %v", err)
+	}
+	out := buf.String()
+
+	var dumped map[string]interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &dumped); err != nil {
+		t.Fatalf("dumped config is not valid YAML: %v\n%s", err, out)
+	}
+	database, _ := dumped["database"].(map[string]interface{})
+	if got := database["dsn"]; got != "mysql://env-host/orders" {
+		t.Errorf("database.dsn = %v, want the overridden value", got)
+	}
+	if got := database["password"]; got != redactedValue {
+		t.Errorf("database.password = %v, want %q", got, redactedValue)
+	}
+	if strings.Contains(out, "file-password") {
+		t.Errorf("dumped config leaks the password:\n%s", out)
+	}
+	// 空的敏感字段输出为空，便于区分"未配置"与"已配置"
+	if got := dumped["token"]; got != "" {
+		t.Errorf("token = %v, want empty for an unset secret", got)
+	}
+	// inline 字段展开到上一层，yaml:"-" 字段不输出
+	if got := dumped["region"]; got != "cn-east" {
+		t.Errorf("region = %v, want the inlined cn-east", got)
+	}
+	if strings.Contains(out, "not-dumped") {
+		t.Errorf("dumped config contains a yaml:\"-\" field:\n%s", out)
+	}
+}
+
+func TestDumpEffectiveConfigAppliesEnvAndRedactsSecrets(t *testing.T) {
+	restoreConfig(t)
+	t.Setenv("NEXUS_KAFKA_BROKERS", "env-broker:9092")
+	t.Setenv("NEXUS_KAFKA_SASL_PASSWORD", "env-password")
+
+	if err := loadConfigFromFile(writeConfigFile(t, dumpTestConfig), false); err != nil {
+		t.Fatalf("loadConfigFromFile returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	cfg := GetCurrentConfig()
+	if err := DumpEffectiveConfig(&buf, &cfg); err != nil {
+		t.Fatalf("DumpEffectiveConfig returned error: %v", err)
+	}
+	out := buf.String()
+
+	var dumped struct {
+		Infra struct {
+			Kafka struct {
+				Brokers string `yaml:"brokers"`
+				SASL    struct {
+					Username string `yaml:"username"`
+					Password string `yaml:"password"`
+				} `yaml:"sasl"`
+			} `yaml:"kafka"`
+			Redis struct {
+				Addrs string `yaml:"addrs"`
+			} `yaml:"redis"`
+		} `yaml:"infra"`
+		App struct {
+			FeatureFlags map[string]interface{} `yaml:"featureFlags"`
+		} `yaml:"app"`
+	}
+	if err := yaml.Unmarshal(buf.Bytes(), &dumped); err != nil {
+		t.Fatalf("dumped config is not valid YAML: %v\n%s", err, out)
+	}
+
+	// 环境变量覆盖文件中的值
+	if got := dumped.Infra.Kafka.Brokers; got != "env-broker:9092" {
+		t.Errorf("brokers = %q, want the env override env-broker:9092", got)
+	}
+	// 未被覆盖的字段保留文件中的值
+	if got := dumped.Infra.Redis.Addrs; got != "redis:6379" {
+		t.Errorf("redis addrs = %q, want redis:6379", got)
+	}
+	if got := dumped.Infra.Kafka.SASL.Username; got != "nexus" {
+		t.Errorf("username = %q, want nexus", got)
+	}
+	// secret 字段被脱敏，无论值来自文件还是环境变量
+	if got := dumped.Infra.Kafka.SASL.Password; got != redactedValue {
+		t.Errorf("password = %q, want %q", got, redactedValue)
+	}
+	if strings.Contains(out, "env-password") || strings.Contains(out, "file-password") {
+		t.Errorf("dumped config leaks the password:\n%s", out)
+	}
+	if got := dumped.App.FeatureFlags["enableVipPromotion"]; got != true {
+		t.Errorf("featureFlags.enableVipPromotion = %v, want true", got)
+	}
+}
+
+func TestEffectiveConfigHandler(t *testing.T) {
+	restoreConfig(t)
+	if err := loadConfigFromFile(writeConfigFile(t, dumpTestConfig), false); err != nil {
+		t.Fatalf("loadConfigFromFile returned error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	EffectiveConfigHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config/effective", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("Content-Type = %q, want application/yaml", ct)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "file-broker:9092") {
+		t.Errorf("body does not contain the configured brokers:\n%s", body)
+	}
+	if strings.Contains(body, "file-password") {
+		t.Errorf("body leaks the password:\n%s", body)
+	}
+}
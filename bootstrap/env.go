@@ -0,0 +1,105 @@
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnvOverrides 使用环境变量覆盖配置中带有 `env` 标签的字段。
+//
+// 配置的优先级从高到低为: 环境变量 > 本地文件/Nacos > 结构体默认值。
+// 每次从文件或 Nacos 加载(包括热更新)之后都会重新应用一次，保证环境变量始终生效。
+// 支持 string、bool、整数、浮点数、time.Duration 以及逗号分隔的 []string。
+func applyEnvOverrides(configPtr interface{}) error {
+	v := reflect.ValueOf(configPtr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("env overrides require a non-nil pointer, got %T", configPtr)
+	}
+	return overrideStruct(v.Elem())
+}
+
+func overrideStruct(v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if key := field.Tag.Get("env"); key != "" {
+			if raw, ok := os.LookupEnv(key); ok {
+				if err := setFromString(fv, raw); err != nil {
+					return fmt.Errorf("invalid value for env %s: %w", key, err)
+				}
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Struct {
+			if err := overrideStruct(fv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setFromString 将环境变量的字符串值解析并写入字段
+func setFromString(fv reflect.Value, raw string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", fv.Type())
+		}
+		parts := strings.Split(raw, ",")
+		slice := reflect.MakeSlice(fv.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			slice.Index(i).SetString(strings.TrimSpace(p))
+		}
+		fv.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
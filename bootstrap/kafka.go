@@ -0,0 +1,74 @@
+package bootstrap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/wangyingjie930/nexus-pkg/mq"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultResiliencePolicy 是 app.resilience.consumers 中作为默认策略的 key，
+// 没有单独配置的消费者与主题都使用该策略；不存在时这些消息的失败处理是关闭的。
+const DefaultResiliencePolicy = "default"
+
+// KafkaSecurity 根据 infra.kafka.sasl 与 infra.kafka.tls 构建 mq.SecurityConfig，
+// 用于 mq.NewSecureKafkaWriter / mq.NewSecureKafkaReader 以及 NewFailureHandlerFromConfig。两者均未配置时返回零值，即明文无认证。
+func (c InfraConfig) KafkaSecurity() (mq.SecurityConfig, error) {
+	var sec mq.SecurityConfig
+
+	mechanism, err := mq.NewSASLMechanism(c.Kafka.SASL.Mechanism, c.Kafka.SASL.Username, c.Kafka.SASL.Password)
+	if err != nil {
+		return sec, fmt.Errorf("invalid kafka SASL config: %w", err)
+	}
+	sec.SASL = mechanism
+
+	if c.Kafka.TLS.Enabled {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: c.Kafka.TLS.InsecureSkipVerify,
+		}
+		if c.Kafka.TLS.CAFile != "" {
+			pem, err := os.ReadFile(c.Kafka.TLS.CAFile)
+			if err != nil {
+				return sec, fmt.Errorf("failed to read kafka CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return sec, fmt.Errorf("no valid certificate found in kafka CA file %s", c.Kafka.TLS.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		sec.TLS = tlsConfig
+	}
+	return sec, nil
+}
+
+// NewFailureHandlerFromConfig 根据 app.resilience.consumers 创建 mq.FailureHandler:
+// 每个 key(消费者名或源主题)对应一套独立的重试/死信策略，DefaultResiliencePolicy 对应默认策略。
+// 消费者在 handler 失败时调用 HandleFor(ctx, consumerName, msg, err) 即可使用自己的策略。
+// sec 通常来自 InfraConfig.KafkaSecurity，写入重试/死信主题时使用。
+func NewFailureHandlerFromConfig(brokers []string, sec mq.SecurityConfig, consumers map[string]ConsumerResilienceConfig, tracer trace.Tracer) *mq.FailureHandler {
+	policies := make(map[string]mq.ResilienceConfig, len(consumers))
+	for name, c := range consumers {
+		if name == DefaultResiliencePolicy {
+			continue
+		}
+		policies[name] = c.toMQ()
+	}
+	return mq.NewSecureFailureHandlerWithPolicies(brokers, consumers[DefaultResiliencePolicy].toMQ(), policies, tracer, sec)
+}
+
+// toMQ 转换为 mq 包的 ResilienceConfig
+func (c ConsumerResilienceConfig) toMQ() mq.ResilienceConfig {
+	return mq.ResilienceConfig{
+		Enabled:             c.Enabled,
+		RetryDelays:         c.RetryDelays,
+		RetryTopicTemplate:  c.RetryTopicTemplate,
+		DltTopicTemplate:    c.DltTopicTemplate,
+		RetryableExceptions: c.RetryableExceptions,
+		MaxHeaderBytes:      c.MaxHeaderBytes,
+	}
+}
@@ -0,0 +1,142 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity 表示一个依赖对服务启动的重要程度
+type Severity int
+
+const (
+	// SeverityCritical 关键依赖，检查失败时整体预检失败，例如 MySQL、Kafka
+	SeverityCritical Severity = iota
+	// SeverityOptional 可选依赖，检查失败只会被报告，不影响整体结果，例如 Jaeger
+	SeverityOptional
+)
+
+func (s Severity) String() string {
+	if s == SeverityOptional {
+		return "optional"
+	}
+	return "critical"
+}
+
+// Dependency 描述一个需要在启动前检查的外部依赖
+type Dependency struct {
+	Name     string
+	Severity Severity
+	Check    func(ctx context.Context) error
+}
+
+// DependencyResult 是单个依赖的检查结果
+type DependencyResult struct {
+	Name     string
+	Severity Severity
+	Err      error
+	Duration time.Duration
+}
+
+// PreflightResult 汇总了所有依赖的检查结果
+type PreflightResult struct {
+	Results []DependencyResult
+}
+
+// Failed 当且仅当存在失败的关键依赖时返回 true，可直接作为部署门禁的退出条件
+func (r PreflightResult) Failed() bool {
+	for _, res := range r.Results {
+		if res.Err != nil && res.Severity == SeverityCritical {
+			return true
+		}
+	}
+	return false
+}
+
+// Degraded 在存在失败的可选依赖时返回 true
+func (r PreflightResult) Degraded() bool {
+	for _, res := range r.Results {
+		if res.Err != nil && res.Severity == SeverityOptional {
+			return true
+		}
+	}
+	return false
+}
+
+// Err 将所有失败的关键依赖汇总为一个错误，没有关键依赖失败时返回 nil
+func (r PreflightResult) Err() error {
+	var failed []string
+	for _, res := range r.Results {
+		if res.Err != nil && res.Severity == SeverityCritical {
+			failed = append(failed, fmt.Sprintf("%s: %v", res.Name, res.Err))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("critical dependencies unavailable: %s", strings.Join(failed, "; "))
+}
+
+// CheckDependencies 并发检查所有依赖，结果顺序与传入顺序一致
+func CheckDependencies(ctx context.Context, deps ...Dependency) PreflightResult {
+	results := make([]DependencyResult, len(deps))
+	var wg sync.WaitGroup
+	for i, dep := range deps {
+		wg.Add(1)
+		go func(i int, dep Dependency) {
+			defer wg.Done()
+			start := time.Now()
+			err := dep.Check(ctx)
+			results[i] = DependencyResult{
+				Name:     dep.Name,
+				Severity: dep.Severity,
+				Err:      err,
+				Duration: time.Since(start),
+			}
+		}(i, dep)
+	}
+	wg.Wait()
+	return PreflightResult{Results: results}
+}
+
+// InfraDependencies 根据基础设施配置生成默认的依赖列表。
+// 未配置的依赖会被跳过；Jaeger 为可选依赖，其余均为关键依赖。
+func InfraDependencies(cfg InfraConfig) []Dependency {
+	var deps []Dependency
+	add := func(name, addrs string, severity Severity) {
+		if addrs == "" {
+			return
+		}
+		deps = append(deps, Dependency{Name: name, Severity: severity, Check: TCPCheck(addrs)})
+	}
+	add("mysql", cfg.Mysql.Addrs, SeverityCritical)
+	add("kafka", cfg.Kafka.Brokers, SeverityCritical)
+	add("redis", cfg.Redis.Addrs, SeverityCritical)
+	add("zookeeper", cfg.Zookeeper.Addrs, SeverityCritical)
+	if cfg.Jaeger.Endpoint != "" && !cfg.Jaeger.Disabled {
+		if u, err := url.Parse(cfg.Jaeger.Endpoint); err == nil && u.Host != "" {
+			add("jaeger", u.Host, SeverityOptional)
+		}
+	}
+	return deps
+}
+
+// TCPCheck 返回一个检查函数，它要求逗号分隔的地址中至少有一个可以建立 TCP 连接
+func TCPCheck(addrs string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var lastErr error
+		var dialer net.Dialer
+		for _, addr := range strings.Split(addrs, ",") {
+			conn, err := dialer.DialContext(ctx, "tcp", strings.TrimSpace(addr))
+			if err == nil {
+				return conn.Close()
+			}
+			lastErr = err
+		}
+		return lastErr
+	}
+}
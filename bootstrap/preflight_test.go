@@ -0,0 +1,122 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+func okCheck(context.Context) error { return nil }
+
+func failingCheck(err error) func(context.Context) error {
+	return func(context.Context) error { return err }
+}
+
+func TestCheckDependenciesSeverity(t *testing.T) {
+	tests := []struct {
+		name         string
+		deps         []Dependency
+		wantFailed   bool
+		wantDegraded bool
+	}{
+		{
+			name: "all healthy",
+			deps: []Dependency{
+				{Name: "mysql", Severity: SeverityCritical, Check: okCheck},
+				{Name: "jaeger", Severity: SeverityOptional, Check: okCheck},
+			},
+		},
+		{
+			name: "optional failure degrades only",
+			deps: []Dependency{
+				{Name: "mysql", Severity: SeverityCritical, Check: okCheck},
+				{Name: "jaeger", Severity: SeverityOptional, Check: failingCheck(errors.New("connection refused"))},
+			},
+			wantDegraded: true,
+		},
+		{
+			name: "critical failure fails",
+			deps: []Dependency{
+				{Name: "mysql", Severity: SeverityCritical, Check: failingCheck(errors.New("connection refused"))},
+				{Name: "jaeger", Severity: SeverityOptional, Check: okCheck},
+			},
+			wantFailed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CheckDependencies(context.Background(), tt.deps...)
+			if got := result.Failed(); got != tt.wantFailed {
+				t.Errorf("Failed() = %v, want %v", got, tt.wantFailed)
+			}
+			if got := result.Degraded(); got != tt.wantDegraded {
+				t.Errorf("Degraded() = %v, want %v", got, tt.wantDegraded)
+			}
+			if err := result.Err(); (err != nil) != tt.wantFailed {
+				t.Errorf("Err() = %v, want error: %v", err, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestCheckDependenciesKeepsOrderAndReportsCriticalOnly(t *testing.T) {
+	result := CheckDependencies(context.Background(),
+		Dependency{Name: "kafka", Severity: SeverityCritical, Check: failingCheck(errors.New("broker down"))},
+		Dependency{Name: "jaeger", Severity: SeverityOptional, Check: failingCheck(errors.New("collector down"))},
+		Dependency{Name: "redis", Severity: SeverityCritical, Check: okCheck},
+	)
+
+	names := make([]string, len(result.Results))
+	for i, r := range result.Results {
+		names[i] = r.Name
+	}
+	if strings.Join(names, ",") != "kafka,jaeger,redis" {
+		t.Errorf("result order = %v, want kafka,jaeger,redis", names)
+	}
+
+	err := result.Err()
+	if err == nil {
+		t.Fatal("Err() = nil, want the kafka failure")
+	}
+	if !strings.Contains(err.Error(), "kafka: broker down") {
+		t.Errorf("Err() = %v, want it to mention kafka", err)
+	}
+	if strings.Contains(err.Error(), "jaeger") {
+		t.Errorf("Err() = %v, want optional failures left out", err)
+	}
+}
+
+func TestInfraDependenciesSkipsUnconfigured(t *testing.T) {
+	var cfg InfraConfig
+	cfg.Mysql.Addrs = "127.0.0.1:3306"
+	cfg.Jaeger.Endpoint = "http://jaeger:14268/api/traces"
+
+	deps := InfraDependencies(cfg)
+	if len(deps) != 2 {
+		t.Fatalf("got %d dependencies, want mysql and jaeger", len(deps))
+	}
+	if deps[0].Name != "mysql" || deps[0].Severity != SeverityCritical {
+		t.Errorf("deps[0] = %s/%s, want mysql/critical", deps[0].Name, deps[0].Severity)
+	}
+	if deps[1].Name != "jaeger" || deps[1].Severity != SeverityOptional {
+		t.Errorf("deps[1] = %s/%s, want jaeger/optional", deps[1].Name, deps[1].Severity)
+	}
+}
+
+func TestTCPCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	// 列表中只要有一个地址可达即视为健康
+	if err := TCPCheck("127.0.0.1:1, " + ln.Addr().String())(context.Background()); err != nil {
+		t.Errorf("TCPCheck returned error for a reachable address: %v", err)
+	}
+	if err := TCPCheck("127.0.0.1:1")(context.Background()); err == nil {
+		t.Error("TCPCheck returned nil for an unreachable address")
+	}
+}
@@ -0,0 +1,114 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+// ShutdownPhase 决定关停钩子的执行顺序。
+// 关停时按阶段从小到大依次执行，同一阶段内的钩子并发执行，前一阶段全部完成后才进入下一阶段。
+type ShutdownPhase int
+
+const (
+	// ShutdownPhaseDeregister 最先执行: 从 Nacos 注销实例，避免新流量进入
+	ShutdownPhaseDeregister ShutdownPhase = 0
+	// ShutdownPhaseServers 关闭 HTTP 服务器，等待进行中的请求完成
+	ShutdownPhaseServers ShutdownPhase = 100
+	// ShutdownPhaseTasks 是 AddTask 的默认阶段，用于停止消费者等后台任务
+	ShutdownPhaseTasks ShutdownPhase = 200
+	// ShutdownPhaseInfra 关闭 Nacos 客户端等基础设施连接
+	ShutdownPhaseInfra ShutdownPhase = 300
+	// ShutdownPhaseTracer 最后执行: 关闭 TracerProvider，保证关停过程中产生的 Span 也能被导出
+	ShutdownPhaseTracer ShutdownPhase = 400
+)
+
+// 单个关停钩子的默认超时
+const defaultStopTimeout = 5 * time.Second
+
+// TaskOption 用于定制 AddTask 注册的任务
+type TaskOption func(*stopHook)
+
+// WithShutdownPhase 指定任务 stop 函数所在的关停阶段，默认为 ShutdownPhaseTasks
+func WithShutdownPhase(phase ShutdownPhase) TaskOption {
+	return func(h *stopHook) {
+		h.phase = phase
+	}
+}
+
+// WithName 为任务命名，任务名会出现在日志以及 Run 返回的错误中，便于定位故障组件
+func WithName(name string) TaskOption {
+	return func(h *stopHook) {
+		h.name = name
+	}
+}
+
+// WithStopTimeout 指定任务 stop 函数的超时，默认为 5 秒
+func WithStopTimeout(timeout time.Duration) TaskOption {
+	return func(h *stopHook) {
+		h.timeout = timeout
+	}
+}
+
+type stopHook struct {
+	name    string
+	phase   ShutdownPhase
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+// shutdownSequence 收集关停钩子，并在关停时按阶段顺序执行
+type shutdownSequence struct {
+	mu    sync.Mutex
+	hooks []stopHook
+}
+
+func (s *shutdownSequence) add(hook stopHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// run 按阶段执行所有钩子，返回所有钩子错误的合并结果
+func (s *shutdownSequence) run() error {
+	s.mu.Lock()
+	hooks := append([]stopHook(nil), s.hooks...)
+	s.mu.Unlock()
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].phase < hooks[j].phase })
+
+	var errs []error
+	for start := 0; start < len(hooks); {
+		end := start
+		for end < len(hooks) && hooks[end].phase == hooks[start].phase {
+			end++
+		}
+		errs = append(errs, runPhase(hooks[start:end])...)
+		start = end
+	}
+	return errors.Join(errs...)
+}
+
+// runPhase 并发执行同一阶段的钩子并等待全部完成
+func runPhase(hooks []stopHook) []error {
+	logger.Logger.Debug().Int("phase", int(hooks[0].phase)).Int("hooks", len(hooks)).Msg("running shutdown phase")
+	errs := make([]error, len(hooks))
+	var wg sync.WaitGroup
+	for i, hook := range hooks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), hook.timeout)
+			defer cancel()
+			if err := hook.stop(ctx); err != nil {
+				errs[i] = fmt.Errorf("stop %s: %w", hook.name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
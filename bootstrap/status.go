@@ -0,0 +1,79 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// ConfigSourceFile 表示配置来自本地文件
+	ConfigSourceFile = "file"
+	// ConfigSourceNacos 表示配置来自 Nacos
+	ConfigSourceNacos = "nacos"
+
+	// ConfigStatusPath 是配置状态管理端点的建议路径
+	ConfigStatusPath = "/config/status"
+)
+
+// ConfigDocumentStatus 描述了单个配置文档(本地文件或 Nacos DataId)的加载状态
+type ConfigDocumentStatus struct {
+	Name       string    `json:"name"`
+	LastReload time.Time `json:"lastReload"`
+}
+
+// ConfigStatus 是 /config/status 端点的响应体，只包含非敏感的元信息
+type ConfigStatus struct {
+	Source    string                 `json:"source"`
+	Documents []ConfigDocumentStatus `json:"documents"`
+}
+
+var (
+	statusLock   sync.RWMutex
+	configSource string
+	// 每个配置文档最近一次成功加载的时间
+	configReloads = make(map[string]time.Time)
+)
+
+// recordConfigReload 记录某个配置文档的一次成功加载
+func recordConfigReload(source, name string) {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	if configSource != source {
+		// 配置来源发生切换(例如文件加载失败回退到 Nacos)，清空旧来源的记录
+		configSource = source
+		configReloads = make(map[string]time.Time)
+	}
+	configReloads[name] = time.Now()
+}
+
+// GetConfigStatus 返回当前配置来源以及各配置文档最近一次成功加载的时间
+func GetConfigStatus() ConfigStatus {
+	statusLock.RLock()
+	defer statusLock.RUnlock()
+
+	status := ConfigStatus{
+		Source:    configSource,
+		Documents: make([]ConfigDocumentStatus, 0, len(configReloads)),
+	}
+	for name, ts := range configReloads {
+		status.Documents = append(status.Documents, ConfigDocumentStatus{Name: name, LastReload: ts})
+	}
+	sort.Slice(status.Documents, func(i, j int) bool {
+		return status.Documents[i].Name < status.Documents[j].Name
+	})
+	return status
+}
+
+// ConfigStatusHandler 返回一个报告配置来源和最近加载时间的 HTTP Handler，
+// 用于确认一次 Nacos 推送是否真正生效。框架不会自动挂载它，需要时由业务方挂载到自己的 mux 上:
+//
+//	mux.Handle(bootstrap.ConfigStatusPath, bootstrap.ConfigStatusHandler())
+func ConfigStatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(GetConfigStatus())
+	})
+}
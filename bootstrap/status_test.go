@@ -0,0 +1,76 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// resetConfigStatus 清空全局的配置加载记录，避免测试之间互相影响
+func resetConfigStatus(t *testing.T) {
+	t.Helper()
+	statusLock.Lock()
+	configSource = ""
+	configReloads = make(map[string]time.Time)
+	statusLock.Unlock()
+	t.Cleanup(func() {
+		statusLock.Lock()
+		configSource = ""
+		configReloads = make(map[string]time.Time)
+		statusLock.Unlock()
+	})
+}
+
+func TestConfigStatusHandler(t *testing.T) {
+	resetConfigStatus(t)
+	before := time.Now()
+	recordConfigReload(ConfigSourceNacos, "order-service.yaml")
+	recordConfigReload(ConfigSourceNacos, "common.yaml")
+
+	mux := http.NewServeMux()
+	mux.Handle(ConfigStatusPath, ConfigStatusHandler())
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ConfigStatusPath, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var status ConfigStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if status.Source != ConfigSourceNacos {
+		t.Errorf("Source = %q, want %q", status.Source, ConfigSourceNacos)
+	}
+	if len(status.Documents) != 2 {
+		t.Fatalf("got %d documents, want 2", len(status.Documents))
+	}
+	// 文档按名称排序
+	if status.Documents[0].Name != "common.yaml" || status.Documents[1].Name != "order-service.yaml" {
+		t.Errorf("documents = %+v, want common.yaml then order-service.yaml", status.Documents)
+	}
+	for _, doc := range status.Documents {
+		if doc.LastReload.Before(before.Add(-time.Second)) {
+			t.Errorf("LastReload of %s = %v, want a recent time", doc.Name, doc.LastReload)
+		}
+	}
+}
+
+func TestConfigStatusSourceSwitchClearsDocuments(t *testing.T) {
+	resetConfigStatus(t)
+	recordConfigReload(ConfigSourceFile, "/etc/app/config.yaml")
+	recordConfigReload(ConfigSourceNacos, "order-service.yaml")
+
+	status := GetConfigStatus()
+	if status.Source != ConfigSourceNacos {
+		t.Errorf("Source = %q, want %q", status.Source, ConfigSourceNacos)
+	}
+	if len(status.Documents) != 1 || status.Documents[0].Name != "order-service.yaml" {
+		t.Errorf("documents = %+v, want only order-service.yaml", status.Documents)
+	}
+}
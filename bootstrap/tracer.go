@@ -0,0 +1,29 @@
+package bootstrap
+
+import (
+	"github.com/wangyingjie930/nexus-pkg/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// initTracerProvider 根据当前配置初始化 TracerProvider，供 v1 和 v2 两条启动路径共用
+func initTracerProvider(serviceName string) (*sdktrace.TracerProvider, error) {
+	jaegerCfg := GetCurrentConfig().Infra.Jaeger
+
+	var opts []tracing.Option
+	if jaegerCfg.SampleRatio > 0 {
+		opts = append(opts, tracing.WithSampleRatio(jaegerCfg.SampleRatio))
+	}
+	if jaegerCfg.BatchTimeout > 0 {
+		opts = append(opts, tracing.WithBatchTimeout(jaegerCfg.BatchTimeout))
+	}
+	if jaegerCfg.MaxExportBatchSize > 0 {
+		opts = append(opts, tracing.WithMaxExportBatchSize(jaegerCfg.MaxExportBatchSize))
+	}
+	if jaegerCfg.MaxQueueSize > 0 {
+		opts = append(opts, tracing.WithMaxQueueSize(jaegerCfg.MaxQueueSize))
+	}
+	if jaegerCfg.Disabled {
+		return tracing.InitNonExportingTracerProvider(serviceName, opts...)
+	}
+	return tracing.InitTracerProvider(serviceName, jaegerCfg.Endpoint, opts...)
+}
@@ -0,0 +1,142 @@
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/wangyingjie930/nexus-pkg/mq"
+)
+
+// ValidationError 汇总了配置中所有缺失或非法的字段，便于一次性修正
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config: %s", strings.Join(e.Problems, "; "))
+}
+
+// ConfigValidator 是可以校验自身的配置。Config 实现了它并内置了基础设施配置的校验；
+// 业务自己的配置结构体(ConfigBinding.Target 返回的目标或 ValidateConfig 的 configHolder)
+// 嵌入 BaseConfig 获得默认的空实现，需要校验时覆盖 Validate 即可:
+//
+//	type OrderConfig struct {
+//		bootstrap.BaseConfig `yaml:",inline"`
+//		MaxItems int `yaml:"maxItems"`
+//	}
+//
+//	func (c *OrderConfig) Validate() error {
+//		if c.MaxItems <= 0 {
+//			return errors.New("maxItems must be positive")
+//		}
+//		return nil
+//	}
+type ConfigValidator interface {
+	Validate() error
+}
+
+// BaseConfig 提供 ConfigValidator 的默认实现，不做任何校验
+type BaseConfig struct{}
+
+// Validate 是默认的空校验，总是返回 nil
+func (BaseConfig) Validate() error { return nil }
+
+// ValidateConfig 校验 path(单个 YAML 文件或包含 *.yaml 文件的目录，与 NEXUS_CONFIG_PATH 相同)中的配置，
+// 不会连接 Nacos、启动服务器，也不会修改当前生效的配置，适合在 CI 中作为合并前的检查。
+// 配置按运行时相同的方式解析(包括环境变量覆盖)，然后执行 Config.Validate。
+//
+// configHolder 不为 nil 时，同样的文件也会被解码到 configHolder 中(例如业务自己的配置结构体)，
+// 如果它实现了 ConfigValidator 也会被校验。所有问题会被汇总后一起返回。
+func ValidateConfig(path string, configHolder interface{}) error {
+	files, err := configFiles(path)
+	if err != nil {
+		return err
+	}
+	var combined CombinedConfig
+	if err := decodeConfigFiles(files, &combined); err != nil {
+		return err
+	}
+	if err := applyEnvOverrides(&combined); err != nil {
+		return err
+	}
+	errs := []error{Config{Infra: combined.Infra, App: combined.App}.Validate()}
+
+	if configHolder != nil {
+		if err := decodeConfigFiles(files, configHolder); err != nil {
+			return err
+		}
+		if v, ok := configHolder.(ConfigValidator); ok {
+			errs = append(errs, v.Validate())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate 校验基础设施配置，返回列出全部问题的 *ValidationError，校验通过时返回 nil。
+// 只校验已经配置的部分: 没有 Kafka 或 Jaeger 的服务(例如使用 mq.NoopWriter 的本地开发环境)不会因此无法启动。
+func (c Config) Validate() error {
+	if problems := c.Infra.problems(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateLoaded 校验新加载的配置快照，以及 bindings 中实现了 ConfigValidator 的业务配置目标，
+// 所有问题汇总后一起返回
+func validateLoaded(cfg *Config, bindings []ConfigBinding) error {
+	errs := []error{cfg.Validate()}
+	for _, b := range bindings {
+		if b.Target == nil {
+			continue
+		}
+		if v, ok := b.Target(cfg).(ConfigValidator); ok {
+			if err := v.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", b.DataId, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c InfraConfig) problems() []string {
+	var problems []string
+
+	// endpoint 为空时使用 Jaeger SDK 的默认地址，禁用导出时不需要 endpoint
+	if c.Jaeger.Endpoint != "" && !c.Jaeger.Disabled {
+		if u, err := url.Parse(c.Jaeger.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("infra.jaeger.endpoint %q is not a valid URL", c.Jaeger.Endpoint))
+		}
+	}
+	if c.Jaeger.SampleRatio < 0 || c.Jaeger.SampleRatio > 1 {
+		problems = append(problems, fmt.Sprintf("infra.jaeger.sampleRatio %v must be within [0, 1]", c.Jaeger.SampleRatio))
+	}
+
+	problems = append(problems, addrProblems("infra.kafka.brokers", c.Kafka.Brokers)...)
+	if c.Kafka.SASL.Mechanism != "" && c.Kafka.Brokers == "" {
+		problems = append(problems, "infra.kafka.sasl is configured but infra.kafka.brokers is empty")
+	}
+	if _, err := mq.NewSASLMechanism(c.Kafka.SASL.Mechanism, c.Kafka.SASL.Username, c.Kafka.SASL.Password); err != nil {
+		problems = append(problems, fmt.Sprintf("infra.kafka.sasl.mechanism: %v", err))
+	}
+	problems = append(problems, addrProblems("infra.redis.addrs", c.Redis.Addrs)...)
+	problems = append(problems, addrProblems("infra.zookeeper.addrs", c.Zookeeper.Addrs)...)
+	problems = append(problems, addrProblems("infra.mysql.addrs", c.Mysql.Addrs)...)
+	return problems
+}
+
+// addrProblems 校验逗号分隔的 host:port 列表，空值视为未配置
+func addrProblems(field, addrs string) []string {
+	if addrs == "" {
+		return nil
+	}
+	var problems []string
+	for _, addr := range strings.Split(addrs, ",") {
+		if _, _, err := net.SplitHostPort(strings.TrimSpace(addr)); err != nil {
+			problems = append(problems, fmt.Sprintf("%s contains invalid address %q", field, addr))
+		}
+	}
+	return problems
+}
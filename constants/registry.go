@@ -0,0 +1,56 @@
+package constants
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Endpoint 将一个接口路径与它所属的服务绑定在一起，避免把某个服务的路径用在另一个服务上
+type Endpoint struct {
+	Service string
+	Path    string
+}
+
+// 各服务已知接口的类型化常量，调用方应优先使用它们而不是手动组合服务名与路径
+var (
+	FraudCheck          = Endpoint{Service: FraudDetectionService, Path: FraudCheckPath}
+	InventoryReserve    = Endpoint{Service: InventoryService, Path: InventoryReservePath}
+	InventoryRelease    = Endpoint{Service: InventoryService, Path: InventoryReleasePath}
+	PromotionPromoPrice = Endpoint{Service: PromotionService, Path: PromotionGetPromoPricePath}
+	PricingCalculate    = Endpoint{Service: PricingService, Path: PricingCalculatePricePath}
+	ShippingGetQuote    = Endpoint{Service: ShippingService, Path: ShippingGetQuotePath}
+)
+
+// servicePaths 记录每个服务对外提供的合法路径，新增路径常量时需要同步登记
+var servicePaths = map[string][]string{
+	FraudDetectionService: {FraudCheckPath},
+	InventoryService:      {InventoryReservePath, InventoryReleasePath},
+	PromotionService:      {PromotionGetPromoPricePath},
+	PricingService:        {PricingCalculatePricePath},
+	ShippingService:       {ShippingGetQuotePath},
+}
+
+// ValidatePath 校验 path 是否属于 service
+func ValidatePath(service, path string) error {
+	paths, ok := servicePaths[service]
+	if !ok {
+		return fmt.Errorf("unknown service %q", service)
+	}
+	if !slices.Contains(paths, path) {
+		return fmt.Errorf("path %q does not belong to service %q", path, service)
+	}
+	return nil
+}
+
+// URLFor 校验服务与路径的对应关系，并返回以服务名为主机名的 URL，例如 http://inventory-service/reserve_stock
+func URLFor(service, path string) (string, error) {
+	if err := ValidatePath(service, path); err != nil {
+		return "", err
+	}
+	return "http://" + service + path, nil
+}
+
+// URL 返回该接口以服务名为主机名的 URL
+func (e Endpoint) URL() string {
+	return "http://" + e.Service + e.Path
+}
@@ -0,0 +1,135 @@
+package grpcclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/wangyingjie930/nexus-pkg/nacos"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Client 是 httpclient.Client 的 gRPC 版本: 通过 Nacos 发现下游实例，
+// 并为每次调用创建客户端 Span、把追踪上下文注入 gRPC metadata
+type Client struct {
+	Tracer      trace.Tracer
+	NacosClient *nacos.Client
+
+	dialOpts []grpc.DialOption
+}
+
+// Option 用于定制 Client 的行为
+type Option func(*Client)
+
+// WithDialOptions 为 DialService 追加 grpc.DialOption，例如 TLS 凭证或 keepalive 参数。
+// 默认使用明文连接，传入 grpc.WithTransportCredentials 即可覆盖。
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(c *Client) {
+		c.dialOpts = append(c.dialOpts, opts...)
+	}
+}
+
+// NewClient 创建一个新的 gRPC 客户端实例
+func NewClient(tracer trace.Tracer, ncClient *nacos.Client, opts ...Option) *Client {
+	c := &Client{
+		Tracer:      tracer,
+		NacosClient: ncClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// DialService 通过 Nacos 发现 serviceName 的一个健康实例并建立连接，
+// 连接上的每次一元调用都会经过 UnaryClientInterceptor。
+// 注意实例只在建立连接时选择一次，调用方应在实例下线(调用返回 Unavailable)后关闭并重新 Dial。
+func (c *Client) DialService(ctx context.Context, serviceName string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	ip, port, err := c.NacosClient.DiscoverServiceInstance(serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover service '%s': %w", serviceName, err)
+	}
+	target := net.JoinHostPort(ip, strconv.Itoa(port))
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(c.UnaryClientInterceptor(serviceName)),
+	}
+	// 后面的选项优先，调用方可以覆盖默认的传输凭证
+	dialOpts = append(dialOpts, c.dialOpts...)
+	dialOpts = append(dialOpts, opts...)
+
+	conn, err := grpc.DialContext(ctx, target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial service '%s' at %s: %w", serviceName, target, err)
+	}
+	return conn, nil
+}
+
+// UnaryClientInterceptor 返回一个一元调用拦截器: 为每次调用创建 SpanKindClient 的 Span，
+// 并使用全局 propagator(与 httpclient 相同)把追踪上下文与 baggage 注入 outgoing metadata。
+// 不通过 DialService 建立的连接也可以用 grpc.WithChainUnaryInterceptor 单独使用它。
+func (c *Client) UnaryClientInterceptor(serviceName string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		spanName := fmt.Sprintf("call-%s", serviceName)
+		ctx, span := c.Tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindClient))
+		defer span.End()
+
+		span.SetAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method),
+			attribute.String("net.peer.name", cc.Target()),
+			attribute.String("service.name.discovered", serviceName),
+		)
+
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+		ctx = metadata.NewOutgoingContext(ctx, md)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		st, _ := status.FromError(err)
+		span.SetAttributes(attribute.String("rpc.grpc.status_code", st.Code().String()))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		return nil
+	}
+}
+
+// metadataCarrier 让 gRPC metadata 满足 propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (m metadataCarrier) Get(key string) string {
+	values := metadata.MD(m).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (m metadataCarrier) Set(key, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
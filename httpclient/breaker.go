@@ -0,0 +1,182 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/wangyingjie930/nexus-pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrCircuitOpen 在下游服务的熔断器处于打开状态时返回，请求不会被发送
+var ErrCircuitOpen = errors.New("httpclient: circuit open")
+
+// BreakerConfig 控制每个下游服务的熔断器
+type BreakerConfig struct {
+	// FailureThreshold 为连续失败多少次后打开熔断器，<= 0 时使用默认值 5
+	FailureThreshold int
+	// Cooldown 为熔断器打开后等待多久进入半开状态，<= 0 时使用默认值 30 秒
+	Cooldown time.Duration
+	// HalfOpenMaxCalls 为半开状态下允许同时通过的探测请求数，<= 0 时使用默认值 1
+	HalfOpenMaxCalls int
+}
+
+// WithCircuitBreaker 为每个下游服务(按服务名区分)开启熔断器:
+// 连续失败达到阈值后打开，冷却期内的请求直接返回 ErrCircuitOpen；冷却期结束后进入半开状态放行少量探测请求，
+// 探测成功则关闭，失败则重新打开。传输错误和 5xx 响应计为失败，4xx 与调用方主动取消不计入。
+func WithCircuitBreaker(cfg BreakerConfig) Option {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+	if cfg.HalfOpenMaxCalls <= 0 {
+		cfg.HalfOpenMaxCalls = 1
+	}
+	return func(c *Client) {
+		c.breakers = &breakerSet{cfg: cfg, breakers: make(map[string]*breaker)}
+	}
+}
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// breakerSet 按服务名管理熔断器
+type breakerSet struct {
+	cfg BreakerConfig
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+func (s *breakerSet) get(service string) *breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.breakers[service]
+	if !ok {
+		b = &breaker{service: service, cfg: s.cfg}
+		s.breakers[service] = b
+	}
+	return b
+}
+
+// breaker 是单个下游服务的熔断器
+type breaker struct {
+	service string
+	cfg     BreakerConfig
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	inFlight int // 半开状态下正在进行的探测请求数
+}
+
+// allow 判断请求是否可以发送，不可以时返回 ErrCircuitOpen
+func (b *breaker) allow(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == stateOpen && time.Since(b.openedAt) >= b.cfg.Cooldown {
+		b.transition(ctx, stateHalfOpen)
+	}
+	switch b.state {
+	case stateOpen:
+		return ErrCircuitOpen
+	case stateHalfOpen:
+		if b.inFlight >= b.cfg.HalfOpenMaxCalls {
+			return ErrCircuitOpen
+		}
+		b.inFlight++
+	}
+	return nil
+}
+
+// record 记录一次请求的结果，b 为 nil(未开启熔断)时不做任何事
+func (b *breaker) record(ctx context.Context, failed bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == stateHalfOpen {
+		b.inFlight = max(b.inFlight-1, 0)
+		if failed {
+			b.transition(ctx, stateOpen)
+		} else {
+			b.transition(ctx, stateClosed)
+		}
+		return
+	}
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == stateClosed && b.failures >= b.cfg.FailureThreshold {
+		b.transition(ctx, stateOpen)
+	}
+}
+
+// release 归还 allow 占用的半开探测名额而不记录结果，用于请求因本地错误(例如构造请求失败)没有发出的情况。
+// b 为 nil(未开启熔断)时不做任何事。
+func (b *breaker) release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == stateHalfOpen {
+		b.inFlight = max(b.inFlight-1, 0)
+	}
+}
+
+// transition 切换状态，并记录日志和 Span 事件。调用方需持有 mu。
+func (b *breaker) transition(ctx context.Context, to breakerState) {
+	from := b.state
+	b.state = to
+	b.failures = 0
+	b.inFlight = 0
+	if to == stateOpen {
+		b.openedAt = time.Now()
+	}
+
+	trace.SpanFromContext(ctx).AddEvent("circuit_breaker.state_change", trace.WithAttributes(
+		attribute.String("service", b.service),
+		attribute.String("from", from.String()),
+		attribute.String("to", to.String()),
+	))
+	log := logger.Ctx(ctx).Info()
+	if to == stateOpen {
+		log = logger.Ctx(ctx).Warn()
+	}
+	log.Str("service", b.service).Str("from", from.String()).Str("to", to.String()).Msg("🔔 Circuit breaker state changed")
+}
+
+// isBreakerFailure 判断一次请求是否应计为熔断失败
+func isBreakerFailure(err error, statusCode int) bool {
+	if err != nil {
+		// 调用方主动取消不代表下游故障
+		return !errors.Is(err, context.Canceled)
+	}
+	return statusCode >= 500
+}
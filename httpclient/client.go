@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -22,24 +23,43 @@ type Client struct {
 	Tracer      trace.Tracer
 	HTTPClient  *http.Client  // ✨ [新增] 持有一个可复用的HTTP客户端实例
 	NacosClient *nacos.Client // ✨ 2. 新增 Nacos 客户端实例
+	// DefaultTimeout 大于 0 时，对没有截止时间的请求使用该超时，见 WithDefaultTimeout
+	DefaultTimeout time.Duration
+
+	// 按下游服务区分的熔断器，为 nil 时不开启，见 WithCircuitBreaker
+	breakers *breakerSet
+
+	// 响应体读取上限与调试日志，见 WithMaxResponseBytes、WithDebugLogging
+	maxResponseBytes int64
+	debugLogging     bool
+	redactKeys       map[string]struct{}
 }
 
 // NewClient 创建一个新的客户端实例
-func NewClient(tracer trace.Tracer, ncClient *nacos.Client) *Client {
+func NewClient(tracer trace.Tracer, ncClient *nacos.Client, opts ...Option) *Client {
 	// ✨ [改造] 在这里创建 http.Client，并且不设置 Timeout 字段
 	// 让其完全受控于每次请求传入的 context
 	httpClient := &http.Client{
-		// 我们可以配置 Transport 来自定义连接池等行为
+		// 默认连接池配置，可以通过 WithConnectionPool 或 WithTransport 替换
 		Transport: &http.Transport{
 			MaxIdleConns:        100,
 			MaxIdleConnsPerHost: 100,
 		},
 	}
-	return &Client{
+	c := &Client{
 		Tracer:      tracer,
 		HTTPClient:  httpClient,
 		NacosClient: ncClient,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewClientWithTransport 使用指定的 Transport 创建客户端，等价于 NewClient(tracer, ncClient, WithTransport(transport))
+func NewClientWithTransport(tracer trace.Tracer, ncClient *nacos.Client, transport *http.Transport, opts ...Option) *Client {
+	return NewClient(tracer, ncClient, append([]Option{WithTransport(transport)}, opts...)...)
 }
 
 // Post 是 callService 的重构版本，作为 Client 的一个方法
@@ -49,11 +69,22 @@ func (c *Client) Post(ctx context.Context, serviceURL string, params url.Values)
 		return err
 	}
 	// 从 URL 中解析出服务名用于 Span
-	spanName := fmt.Sprintf("call-%s", strings.Split(parsedURL.Host, ":")[0])
+	service := strings.Split(parsedURL.Host, ":")[0]
+	spanName := fmt.Sprintf("call-%s", service)
+
+	ctx, cancel := c.withDefaultTimeout(ctx)
+	defer cancel()
 
 	ctx, span := c.Tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindClient))
 	defer span.End()
 
+	b, err := c.allow(ctx, service)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
 	downstreamURL := *parsedURL
 	q := downstreamURL.Query()
 	for key, values := range params {
@@ -65,42 +96,59 @@ func (c *Client) Post(ctx context.Context, serviceURL string, params url.Values)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", downstreamURL.String(), nil)
 	if err != nil {
+		// 本地错误与下游健康无关，不计入熔断结果
+		b.release()
 		span.RecordError(err)
 		return err
 	}
 
 	span.SetAttributes(
-		attribute.String("http.url", downstreamURL.String()),
+		attribute.String("http.url", c.redactURL(downstreamURL.String())),
 		attribute.String("http.method", "POST"),
 	)
 	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return err
+	return c.send(ctx, span, b, req, downstreamURL.String())
+}
+
+// CallService 方法现在通过服务名进行调用
+// serviceName: 要调用的服务名, e.g., "inventory-service"
+// requestPath: 具体的请求路径, e.g., "/reserve_stock"
+// opts: 可选的调用选项, e.g., WithStickyKey(userID)
+func (c *Client) CallService(ctx context.Context, serviceName, requestPath string, params url.Values, opts ...CallOption) error {
+	var callOpts callOptions
+	for _, opt := range opts {
+		opt(&callOpts)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		err := fmt.Errorf("service %s returned status %s", serviceURL, resp.Status)
+	// 从 serviceName 中解析出服务名用于 Span
+	spanName := fmt.Sprintf("call-%s", serviceName)
+
+	ctx, cancel := c.withDefaultTimeout(ctx)
+	defer cancel()
+
+	// 先创建 Span，熔断拒绝与服务发现失败同样会出现在链路中
+	ctx, span := c.Tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	// 熔断器打开时直接失败，既不发现实例也不发送请求
+	b, err := c.allow(ctx, serviceName)
+	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return err
 	}
-	return nil
-}
 
-// CallService 方法现在通过服务名进行调用
-// serviceName: 要调用的服务名, e.g., "inventory-service"
-// requestPath: 具体的请求路径, e.g., "/reserve_stock"
-func (c *Client) CallService(ctx context.Context, serviceName, requestPath string, params url.Values) error {
 	// ✨ 5. 核心改造：通过 Nacos 发现服务实例
-	instanceIP, instancePort, err := c.NacosClient.DiscoverServiceInstance(serviceName)
+	instanceIP, instancePort, err := c.resolveInstance(serviceName, callOpts)
 	if err != nil {
+		// 没有可用实例同样说明下游不可用，计入熔断失败
+		b.record(ctx, true)
 		// 服务发现失败是严重错误，直接返回
-		return fmt.Errorf("failed to discover service '%s': %w", serviceName, err)
+		err = fmt.Errorf("failed to discover service '%s': %w", serviceName, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
 	}
 
 	// 动态构建下游服务的 URL，将参数作为查询参数
@@ -111,12 +159,6 @@ func (c *Client) CallService(ctx context.Context, serviceName, requestPath strin
 		serviceURL += "?" + params.Encode()
 	}
 
-	// 从 serviceName 中解析出服务名用于 Span
-	spanName := fmt.Sprintf("call-%s", serviceName)
-
-	ctx, span := c.Tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindClient))
-	defer span.End()
-
 	// 将发现的实例信息记录到 Span 中，便于追踪和调试
 	span.SetAttributes(
 		attribute.String("net.peer.name", instanceIP),
@@ -126,26 +168,52 @@ func (c *Client) CallService(ctx context.Context, serviceName, requestPath strin
 
 	req, err := http.NewRequestWithContext(ctx, "POST", serviceURL, nil)
 	if err != nil {
+		// 本地错误与下游健康无关，不计入熔断结果
+		b.release()
 		span.RecordError(err)
 		return err
 	}
 
 	span.SetAttributes(
-		attribute.String("http.url", serviceURL),
+		attribute.String("http.url", c.redactURL(serviceURL)),
 		attribute.String("http.method", "POST"),
 	)
 	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
+	return c.send(ctx, span, b, req, serviceURL)
+}
+
+// allow 检查下游服务的熔断器，未开启熔断时返回 nil 熔断器
+func (c *Client) allow(ctx context.Context, service string) (*breaker, error) {
+	if c.breakers == nil {
+		return nil, nil
+	}
+	b := c.breakers.get(service)
+	if err := b.allow(ctx); err != nil {
+		return nil, fmt.Errorf("call to service '%s' rejected: %w", service, err)
+	}
+	return b, nil
+}
+
+// send 发送请求并检查响应状态码，结果会记录到 span 与熔断器 b(可以为 nil)中
+// 响应体只在上限内读取，用于调试日志片段以及连接复用。
+func (c *Client) send(ctx context.Context, span trace.Span, b *breaker, req *http.Request, serviceURL string) error {
+	start := time.Now()
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
+		b.record(ctx, isBreakerFailure(err, 0))
+		c.logCall(ctx, serviceURL, 0, nil, time.Since(start), err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return err
 	}
 	defer resp.Body.Close()
+	b.record(ctx, isBreakerFailure(nil, resp.StatusCode))
+	snippet := c.readResponse(resp.Body, c.debugLogging && resp.StatusCode != http.StatusOK)
+	c.logCall(ctx, serviceURL, resp.StatusCode, snippet, time.Since(start), nil)
 
 	if resp.StatusCode != http.StatusOK {
-		err := fmt.Errorf("service %s returned status %s", serviceURL, resp.Status)
+		err := fmt.Errorf("service %s returned status %s", c.redactURL(serviceURL), resp.Status)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return err
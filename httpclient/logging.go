@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+const (
+	// defaultMaxResponseBytes 是未配置 WithMaxResponseBytes 时读取响应体的上限
+	defaultMaxResponseBytes = 64 << 10
+	// snippetBytes 是日志中响应体片段的最大长度
+	snippetBytes = 512
+	redacted     = "REDACTED"
+)
+
+// WithMaxResponseBytes 设置最多读取的响应体字节数，防止异常的下游返回超大响应导致 OOM。
+// 客户端只读取响应体用于日志片段，以及在上限内丢弃剩余内容以便复用连接；超过上限的部分不会被读取。
+// 默认为 64KB。
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Client) {
+		c.maxResponseBytes = n
+	}
+}
+
+// WithDebugLogging 开启调试日志: 每次调用都会以 debug 级别记录请求 URL、状态码和耗时，
+// 失败时以 warn 级别附带有长度限制的响应体片段。
+// redactKeys 中的查询参数(不区分大小写)在日志、Span 属性和错误信息中会被替换为 REDACTED，例如 token、phone。
+func WithDebugLogging(redactKeys ...string) Option {
+	return func(c *Client) {
+		c.debugLogging = true
+		if c.redactKeys == nil {
+			c.redactKeys = make(map[string]struct{}, len(redactKeys))
+		}
+		for _, key := range redactKeys {
+			c.redactKeys[strings.ToLower(key)] = struct{}{}
+		}
+	}
+}
+
+// redactURL 将敏感查询参数的值替换为 REDACTED
+func (c *Client) redactURL(raw string) string {
+	if len(c.redactKeys) == 0 {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	changed := false
+	for key, values := range q {
+		if _, ok := c.redactKeys[strings.ToLower(key)]; ok {
+			for i := range values {
+				values[i] = redacted
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return raw
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// readResponse 在上限内读取响应体: 需要时返回开头的片段，并丢弃其余内容以便连接复用
+func (c *Client) readResponse(body io.Reader, wantSnippet bool) []byte {
+	limit := c.maxResponseBytes
+	if limit <= 0 {
+		limit = defaultMaxResponseBytes
+	}
+	limited := io.LimitReader(body, limit)
+	var snippet []byte
+	if wantSnippet {
+		snippet, _ = io.ReadAll(io.LimitReader(limited, snippetBytes))
+	}
+	_, _ = io.Copy(io.Discard, limited)
+	return snippet
+}
+
+// logCall 输出调试日志，未开启 WithDebugLogging 时不做任何事
+func (c *Client) logCall(ctx context.Context, rawURL string, status int, snippet []byte, elapsed time.Duration, err error) {
+	if !c.debugLogging {
+		return
+	}
+	log := logger.Ctx(ctx)
+	if err != nil || status >= 400 {
+		log.Warn().Err(err).Str("url", c.redactURL(rawURL)).Int("status", status).Dur("elapsed", elapsed).
+			Bytes("response_snippet", snippet).Msg("⚠️ Downstream call failed")
+		return
+	}
+	log.Debug().Str("url", c.redactURL(rawURL)).Int("status", status).Dur("elapsed", elapsed).Msg("downstream call completed")
+}
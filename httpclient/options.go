@@ -0,0 +1,63 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Option 用于定制 Client 的行为
+type Option func(*Client)
+
+// WithDefaultTimeout 为没有截止时间的请求设置默认超时，避免调用方忘记设置 deadline 时
+// 在卡住的下游上无限期等待。调用方传入的 context 已有截止时间时以调用方为准(无论长短)，
+// 因此调用方总是可以用更短(或更长)的 deadline 覆盖该默认值。
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.DefaultTimeout = timeout
+	}
+}
+
+// withDefaultTimeout 在 ctx 没有截止时间且配置了默认超时时，为其加上默认超时
+func (c *Client) withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.DefaultTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.DefaultTimeout)
+}
+
+// PoolConfig 描述连接池与拨号参数，零值字段表示不限制或使用 Go 的默认行为
+type PoolConfig struct {
+	MaxIdleConns        int           // 所有下游的最大空闲连接数
+	MaxIdleConnsPerHost int           // 每个下游的最大空闲连接数
+	MaxConnsPerHost     int           // 每个下游的最大连接数(包括使用中的连接)
+	IdleConnTimeout     time.Duration // 空闲连接的最长保留时间
+	DialTimeout         time.Duration // 建立 TCP 连接的超时
+}
+
+// WithConnectionPool 使用 cfg 创建新的 Transport 替换默认的连接池配置。
+// 高扇出的网关可以调大 MaxIdleConnsPerHost，只调用少数下游的服务可以调小以节省连接。
+func WithConnectionPool(cfg PoolConfig) Option {
+	return func(c *Client) {
+		dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}
+		c.HTTPClient.Transport = &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         dialer.DialContext,
+			MaxIdleConns:        cfg.MaxIdleConns,
+			MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     cfg.MaxConnsPerHost,
+			IdleConnTimeout:     cfg.IdleConnTimeout,
+		}
+	}
+}
+
+// WithTransport 使用调用方提供的 RoundTripper，例如自定义了 TLS 或代理的 *http.Transport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.HTTPClient.Transport = transport
+	}
+}
@@ -0,0 +1,59 @@
+package httpclient
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/model"
+)
+
+// CallOption 用于定制单次 CallService 调用的行为
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	stickyKey string
+}
+
+// WithStickyKey 让相同 key 的请求始终路由到同一个实例。
+// 实例选择采用最高随机权重(Rendezvous)哈希: key 在健康实例之间均匀分布，
+// 实例增减时只有原本落在变动实例上的 key 会被重新分配。
+func WithStickyKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.stickyKey = key
+	}
+}
+
+// resolveInstance 根据调用选项从 Nacos 选择一个下游实例
+func (c *Client) resolveInstance(serviceName string, opts callOptions) (string, int, error) {
+	if opts.stickyKey == "" {
+		// 使用 Nacos 内置的负载均衡算法
+		return c.NacosClient.DiscoverServiceInstance(serviceName)
+	}
+
+	instances, err := c.NacosClient.DiscoverAllInstances(serviceName)
+	if err != nil {
+		return "", 0, err
+	}
+	instance := pickStickyInstance(instances, opts.stickyKey)
+	return instance.Ip, int(instance.Port), nil
+}
+
+// pickStickyInstance 为 key 选出哈希得分最高的实例，instances 不能为空
+func pickStickyInstance(instances []model.Instance, key string) model.Instance {
+	best := instances[0]
+	var bestScore uint64
+	for i, instance := range instances {
+		score := stickyScore(key, instance)
+		if i == 0 || score > bestScore {
+			best, bestScore = instance, score
+		}
+	}
+	return best
+}
+
+// stickyScore 计算 key 与实例组合的哈希得分，实例以 ip:port 标识以保证跨进程稳定
+func stickyScore(key string, instance model.Instance) uint64 {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%s|%s:%d", key, instance.Ip, instance.Port)
+	return h.Sum64()
+}
@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/model"
+)
+
+func testInstances(n int) []model.Instance {
+	instances := make([]model.Instance, n)
+	for i := range instances {
+		instances[i] = model.Instance{Ip: fmt.Sprintf("10.0.0.%d", i+1), Port: 8080}
+	}
+	return instances
+}
+
+func instanceAddr(instance model.Instance) string {
+	return fmt.Sprintf("%s:%d", instance.Ip, instance.Port)
+}
+
+func TestPickStickyInstanceIsStable(t *testing.T) {
+	instances := testInstances(5)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		first := pickStickyInstance(instances, key)
+
+		// 实例列表的顺序不应影响选择结果
+		reversed := make([]model.Instance, len(instances))
+		for j, instance := range instances {
+			reversed[len(instances)-1-j] = instance
+		}
+		if got := pickStickyInstance(reversed, key); instanceAddr(got) != instanceAddr(first) {
+			t.Fatalf("key %s mapped to %s, then to %s after reordering", key, instanceAddr(first), instanceAddr(got))
+		}
+		if got := pickStickyInstance(instances, key); instanceAddr(got) != instanceAddr(first) {
+			t.Fatalf("key %s mapped to %s, then to %s", key, instanceAddr(first), instanceAddr(got))
+		}
+	}
+}
+
+func TestPickStickyInstanceMinimalRedistribution(t *testing.T) {
+	instances := testInstances(5)
+	removed := instances[2]
+	remaining := append(append([]model.Instance{}, instances[:2]...), instances[3:]...)
+
+	const keys = 2000
+	moved, onRemoved := 0, 0
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		before := pickStickyInstance(instances, key)
+		after := pickStickyInstance(remaining, key)
+		if instanceAddr(before) == instanceAddr(removed) {
+			onRemoved++
+			continue
+		}
+		// 原本不在下线实例上的 key 不应被重新分配
+		if instanceAddr(before) != instanceAddr(after) {
+			moved++
+		}
+	}
+	if moved != 0 {
+		t.Errorf("%d keys not on the removed instance were redistributed, want 0", moved)
+	}
+	// key 应在实例之间大致均匀分布
+	if want := keys / len(instances); onRemoved < want/2 || onRemoved > want*2 {
+		t.Errorf("%d of %d keys were on the removed instance, want roughly %d", onRemoved, keys, want)
+	}
+}
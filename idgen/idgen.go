@@ -0,0 +1,110 @@
+// Package idgen 提供全局唯一且按时间有序的 ID 生成器，
+// 用于请求 ID、事务消息的业务 ID 等需要跨进程唯一的场景。
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Generator 定义了 ID 生成器。实现必须是并发安全的。
+// 默认使用 ULID，如需 Snowflake 等其他算法，可以实现该接口后通过 SetDefault 替换。
+type Generator interface {
+	NewID() string
+}
+
+var defaultGenerator atomic.Value
+
+func init() {
+	defaultGenerator.Store(holder{NewULIDGenerator()})
+}
+
+// holder 保证 atomic.Value 中存储的具体类型始终一致
+type holder struct {
+	Generator
+}
+
+// SetDefault 替换全局默认的 ID 生成器
+func SetDefault(g Generator) {
+	defaultGenerator.Store(holder{g})
+}
+
+// New 使用全局默认生成器生成一个新 ID
+func New() string {
+	return defaultGenerator.Load().(holder).NewID()
+}
+
+// crockford 是 ULID 使用的 Crockford Base32 字母表
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDGenerator 生成单调递增的 ULID (48 位毫秒时间戳 + 80 位随机数)。
+// 同一毫秒内生成的 ID 会在上一个 ID 的随机部分上加一，从而保证进程内严格单调。
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	lastMs  uint64
+	randHi  uint16
+	randLo  uint64
+	nowFunc func() time.Time
+}
+
+// NewULIDGenerator 创建一个 ULID 生成器
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{nowFunc: time.Now}
+}
+
+// NewID 实现 Generator 接口
+func (g *ULIDGenerator) NewID() string {
+	g.mu.Lock()
+	ms := uint64(g.nowFunc().UnixMilli())
+	if ms > g.lastMs {
+		g.lastMs = ms
+		g.randHi, g.randLo = randomEntropy()
+	} else {
+		// 同一毫秒(或时钟回拨)内，在上一个 ID 的基础上递增
+		g.randLo++
+		if g.randLo == 0 {
+			g.randHi++
+			if g.randHi == 0 {
+				// 随机部分溢出，借用下一毫秒
+				g.lastMs++
+			}
+		}
+	}
+	hi := g.lastMs<<16 | uint64(g.randHi)
+	lo := g.randLo
+	g.mu.Unlock()
+
+	return encodeULID(hi, lo)
+}
+
+// randomEntropy 生成 80 位随机数
+func randomEntropy() (uint16, uint64) {
+	var b [10]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand 失败时退化为时间种子，仍然能保证进程内单调
+		binary.BigEndian.PutUint64(b[2:], uint64(time.Now().UnixNano()))
+	}
+	return binary.BigEndian.Uint16(b[:2]), binary.BigEndian.Uint64(b[2:])
+}
+
+// encodeULID 将 128 位数值编码为 26 个字符的 Crockford Base32 字符串
+func encodeULID(hi, lo uint64) string {
+	var out [26]byte
+	for i := 0; i < 26; i++ {
+		shift := uint(125 - 5*i)
+		var v uint64
+		switch {
+		case shift >= 64:
+			v = hi >> (shift - 64)
+		case shift == 0:
+			v = lo
+		default:
+			v = hi<<(64-shift) | lo>>shift
+		}
+		out[i] = crockford[v&31]
+	}
+	return string(out[:])
+}
@@ -0,0 +1,98 @@
+package idgen
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestULIDFormat(t *testing.T) {
+	id := NewULIDGenerator().NewID()
+	if len(id) != 26 {
+		t.Fatalf("len(%q) = %d, want 26", id, len(id))
+	}
+	for _, c := range id {
+		if !strings.ContainsRune(crockford, c) {
+			t.Fatalf("%q contains %q, which is not in the Crockford alphabet", id, c)
+		}
+	}
+}
+
+func TestULIDMonotonicWithinSameMillisecond(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	g := &ULIDGenerator{nowFunc: func() time.Time { return now }}
+
+	prev := g.NewID()
+	for i := 0; i < 10000; i++ {
+		id := g.NewID()
+		if id <= prev {
+			t.Fatalf("id %q is not greater than previous %q", id, prev)
+		}
+		prev = id
+	}
+}
+
+func TestULIDMonotonicWhenClockGoesBackwards(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	g := &ULIDGenerator{nowFunc: func() time.Time { return now }}
+
+	first := g.NewID()
+	now = now.Add(-time.Second)
+	if second := g.NewID(); second <= first {
+		t.Fatalf("id %q after clock rollback is not greater than %q", second, first)
+	}
+}
+
+func TestULIDTimestampPrefixIsOrdered(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	g := &ULIDGenerator{nowFunc: func() time.Time { return now }}
+
+	earlier := g.NewID()
+	now = now.Add(time.Millisecond)
+	later := g.NewID()
+	// 前 10 个字符编码 48 位毫秒时间戳
+	if earlier[:10] >= later[:10] {
+		t.Errorf("timestamp prefix %q is not before %q", earlier[:10], later[:10])
+	}
+}
+
+func TestULIDConcurrentUniqueness(t *testing.T) {
+	g := NewULIDGenerator()
+	const goroutines, perGoroutine = 16, 2000
+
+	ids := make(chan string, goroutines*perGoroutine)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				ids <- g.NewID()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]struct{}, goroutines*perGoroutine)
+	for id := range ids {
+		if _, ok := seen[id]; ok {
+			t.Fatalf("duplicate id %q", id)
+		}
+		seen[id] = struct{}{}
+	}
+}
+
+type fixedGenerator string
+
+func (g fixedGenerator) NewID() string { return string(g) }
+
+func TestSetDefault(t *testing.T) {
+	t.Cleanup(func() { SetDefault(NewULIDGenerator()) })
+
+	SetDefault(fixedGenerator("fixed"))
+	if got := New(); got != "fixed" {
+		t.Errorf("New() = %q, want fixed", got)
+	}
+}
@@ -3,33 +3,212 @@ package logger
 import (
 	"context"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/trace"
+	"io"
+	"math/rand/v2"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 )
 
 // Logger 是一个全局的、配置好的 zerolog 实例
 var Logger zerolog.Logger
 
+var (
+	initLock sync.Mutex
+	// 当前 Logger 所使用的服务名，为空表示尚未初始化
+	initializedService string
+	// 当前 Logger 所使用的选项，With 据此重建 Logger
+	currentOptions LoggerOptions
+	// 由 With 添加的静态字段，重新初始化 Logger 后依然保留
+	staticFields = map[string]interface{}{}
+
+	baggageLock sync.RWMutex
+	// 需要写入日志的 baggage 键，默认不记录任何 baggage，避免泄露敏感信息
+	baggageKeys []string
+)
+
+// RegisterBaggageKeys 注册需要由 Ctx 写入日志的 OpenTelemetry baggage 键，例如 tenant_id、request_id。
+// 只有注册过的键才会被记录，字段名与键名相同。
+func RegisterBaggageKeys(keys ...string) {
+	baggageLock.Lock()
+	defer baggageLock.Unlock()
+	for _, key := range keys {
+		if !containsKey(baggageKeys, key) {
+			baggageKeys = append(baggageKeys, key)
+		}
+	}
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// LoggerOptions 控制全局 Logger 的输出行为
+type LoggerOptions struct {
+	// Level 为最低输出级别。注意零值是 zerolog.DebugLevel，建议从 DefaultLoggerOptions 开始修改
+	Level zerolog.Level
+	// ConsoleWriter 为 true 时输出便于阅读的彩色文本而不是 JSON，适用于本地开发
+	ConsoleWriter bool
+	// Writer 为日志输出目标，为 nil 时使用 os.Stdout。测试中可以传入 *bytes.Buffer 来断言日志字段
+	Writer io.Writer
+	// Fields 为附加到每一行日志上的静态字段，例如 version、region、instance_id，
+	// 与 With 添加的字段合并，同名时以 Fields 为准
+	Fields map[string]interface{}
+	// SampleRate 为 trace/debug/info 级别日志的采样比例，取值 (0, 1)，例如 0.01 表示只输出 1%。
+	// warn 及以上级别永远不会被采样丢弃；带调试标记的请求(见 IsForceDebug)也不受采样影响。
+	// 为 0 或 >= 1 时不采样。
+	SampleRate float64
+}
+
+// rateSampler 按比例随机采样，只作用于 info 及以下级别
+type rateSampler struct {
+	rate float64
+}
+
+func (s rateSampler) Sample(lvl zerolog.Level) bool {
+	if lvl >= zerolog.WarnLevel || lvl == zerolog.NoLevel {
+		return true
+	}
+	return rand.Float64() < s.rate
+}
+
+// DefaultLoggerOptions 返回默认选项: JSON 输出，级别读取自 LOG_LEVEL 环境变量(如 debug、info、warn)，
+// 未设置或无法解析时不过滤任何级别，与 Init 的历史行为保持一致。
+func DefaultLoggerOptions() LoggerOptions {
+	level := zerolog.TraceLevel
+	if raw, ok := os.LookupEnv("LOG_LEVEL"); ok {
+		if parsed, err := zerolog.ParseLevel(raw); err == nil && raw != "" {
+			level = parsed
+		}
+	}
+	return LoggerOptions{Level: level}
+}
+
+// Init 初始化全局 Logger。它可以被安全地重复调用:
+// 使用相同的服务名再次调用是一个空操作，不会重建 Logger。
 func Init(serviceName string) {
+	initLock.Lock()
+	defer initLock.Unlock()
+	if initializedService == serviceName {
+		return
+	}
+	initLocked(serviceName, DefaultLoggerOptions())
+}
+
+// InitWithOptions 使用指定的选项初始化全局 Logger。与 Init 不同，它总是会重建 Logger。
+func InitWithOptions(serviceName string, opts LoggerOptions) {
+	initLock.Lock()
+	defer initLock.Unlock()
+	initLocked(serviceName, opts)
+}
+
+// InitOnce 仅在 Logger 尚未初始化时才进行初始化。
+// 它用于引导阶段的临时 Logger，避免覆盖业务已经设置好的服务名。
+func InitOnce(serviceName string) {
+	initLock.Lock()
+	defer initLock.Unlock()
+	if initializedService != "" {
+		return
+	}
+	initLocked(serviceName, DefaultLoggerOptions())
+}
+
+// With 为全局 Logger 添加静态字段，例如 version、region、instance_id。
+// 之后所有直接使用 Logger 以及通过 Ctx 得到的日志都会带上这些字段，Logger 重新初始化后依然保留。
+// 同名字段会覆盖之前的值，而不是重复输出。
+func With(fields map[string]interface{}) {
+	initLock.Lock()
+	defer initLock.Unlock()
+	for k, v := range fields {
+		staticFields[k] = v
+	}
+	// 尚未初始化时只记录字段，Init 时再一并写入
+	if initializedService != "" {
+		initLocked(initializedService, currentOptions)
+	}
+}
+
+// initLocked 构建全局 Logger，调用方需持有 initLock
+func initLocked(serviceName string, opts LoggerOptions) {
+	initializedService = serviceName
+	currentOptions = opts
+
 	// zerolog 的一些默认配置，以实现更佳的性能和结构
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnixMs // 使用毫秒级时间戳
 	zerolog.LevelFieldName = "level"
 	zerolog.MessageFieldName = "msg"
 	zerolog.TimestampFieldName = "ts"
 
+	out := opts.Writer
+	if out == nil {
+		out = os.Stdout
+	}
+	if opts.ConsoleWriter {
+		out = zerolog.ConsoleWriter{Out: out, TimeFormat: time.TimeOnly}
+	}
+
 	// 创建一个带有一致性字段的 Logger 实例
 	// 在真实的生产环境中，可以从配置中读取服务名
-	Logger = zerolog.New(os.Stdout).With().
+	fields := make(map[string]interface{}, len(staticFields)+len(opts.Fields))
+	for k, v := range staticFields {
+		fields[k] = v
+	}
+	for k, v := range opts.Fields {
+		fields[k] = v
+	}
+	Logger = zerolog.New(out).Level(opts.Level).With().
 		Timestamp().
 		Str("service_name", serviceName). // 从环境变量获取服务名
+		Fields(fields).
 		Logger()
+	if opts.SampleRate > 0 && opts.SampleRate < 1 {
+		Logger = Logger.Sample(rateSampler{rate: opts.SampleRate})
+	}
+}
+
+// ForceDebugBaggageKey 是开启单请求调试日志的 baggage 键，值为 true/1 时生效。
+// 通过 baggage 传播可以让整条链路上的服务都输出该请求的调试日志。
+const ForceDebugBaggageKey = "force-debug"
+
+type forceDebugKey struct{}
+
+// WithForceDebug 标记该 context 对应的请求需要输出调试日志
+func WithForceDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceDebugKey{}, true)
+}
+
+// IsForceDebug 判断 context 是否带有调试标记(来自 WithForceDebug 或 force-debug baggage)
+func IsForceDebug(ctx context.Context) bool {
+	if forced, _ := ctx.Value(forceDebugKey{}).(bool); forced {
+		return true
+	}
+	forced, _ := strconv.ParseBool(baggage.FromContext(ctx).Member(ForceDebugBaggageKey).Value())
+	return forced
 }
 
 // Ctx 返回一个带有从 context 中提取的追踪信息的子 logger。
 // 这是将日志与链路追踪关联起来的关键。
+// 除 trace_id/span_id 外，还会写入通过 RegisterBaggageKeys 注册的 baggage 成员。
+// 如果请求带有调试标记(见 IsForceDebug)，返回的 logger 会把级别放宽到 debug，其余请求仍遵循全局级别。
 func Ctx(ctx context.Context) *zerolog.Logger {
 	log := Logger // 从全局 logger 开始
 
+	if IsForceDebug(ctx) {
+		// 调试请求的日志不参与采样
+		log = log.Sample(nil)
+		if log.GetLevel() > zerolog.DebugLevel {
+			log = log.Level(zerolog.DebugLevel).With().Bool("force_debug", true).Logger()
+		}
+	}
+
 	// 从 context 中获取 Span，并提取 TraceID 和 SpanID
 	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
 		log = log.With().
@@ -37,5 +216,21 @@ func Ctx(ctx context.Context) *zerolog.Logger {
 			Str("span_id", span.SpanContext().SpanID().String()).
 			Logger()
 	}
+
+	baggageLock.RLock()
+	keys := baggageKeys
+	baggageLock.RUnlock()
+	if len(keys) > 0 {
+		bag := baggage.FromContext(ctx)
+		if bag.Len() > 0 {
+			fields := log.With()
+			for _, key := range keys {
+				if member := bag.Member(key); member.Key() != "" {
+					fields = fields.Str(key, member.Value())
+				}
+			}
+			log = fields.Logger()
+		}
+	}
 	return &log
 }
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// resetLogger 恢复全局 Logger 的初始状态，避免测试之间互相影响
+func resetLogger(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		initLock.Lock()
+		defer initLock.Unlock()
+		Logger = zerolog.Logger{}
+		initializedService = ""
+		currentOptions = LoggerOptions{}
+		staticFields = map[string]interface{}{}
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestInitIsIdempotentForSameService(t *testing.T) {
+	resetLogger(t)
+	var buf bytes.Buffer
+	InitWithOptions("order-service", LoggerOptions{Level: zerolog.InfoLevel, Writer: &buf})
+
+	// 相同服务名的 Init 与 InitOnce 都不应重建 Logger(否则输出会切换到 os.Stdout)
+	Init("order-service")
+	InitOnce("bootstrap")
+	Logger.Info().Msg("after repeated init")
+
+	out := buf.String()
+	if !strings.Contains(out, "after repeated init") {
+		t.Fatalf("log output = %q, want it to still go to the original writer", out)
+	}
+	if !strings.Contains(out, `"service_name":"order-service"`) {
+		t.Errorf("log output = %q, want service_name order-service", out)
+	}
+}
+
+func TestInitOnceDoesNotOverrideInitializedLogger(t *testing.T) {
+	resetLogger(t)
+	var buf bytes.Buffer
+	InitWithOptions("payment-service", LoggerOptions{Level: zerolog.InfoLevel, Writer: &buf})
+
+	InitOnce("bootstrap")
+	Logger.Info().Msg("hello")
+
+	if out := buf.String(); !strings.Contains(out, `"service_name":"payment-service"`) {
+		t.Errorf("log output = %q, want service_name payment-service", out)
+	}
+}
+
+func TestInitWithDifferentServiceRebuildsLogger(t *testing.T) {
+	resetLogger(t)
+	var buf bytes.Buffer
+	InitWithOptions("bootstrap", LoggerOptions{Level: zerolog.InfoLevel, Writer: &buf})
+
+	Init("order-service")
+
+	initLock.Lock()
+	service := initializedService
+	initLock.Unlock()
+	if service != "order-service" {
+		t.Errorf("initialized service = %q, want order-service", service)
+	}
+}
+
+func TestSampleRateDropsInfoButKeepsWarnAndError(t *testing.T) {
+	resetLogger(t)
+	var buf bytes.Buffer
+	InitWithOptions("order-service", LoggerOptions{Level: zerolog.InfoLevel, Writer: &buf, SampleRate: 0.01})
+
+	const n = 2000
+	for i := 0; i < n; i++ {
+		Logger.Info().Int("i", i).Msg("order processed")
+		Logger.Warn().Int("i", i).Msg("inventory low")
+		Logger.Error().Int("i", i).Msg("payment failed")
+	}
+
+	out := buf.String()
+	infos := strings.Count(out, `"level":"info"`)
+	warns := strings.Count(out, `"level":"warn"`)
+	errs := strings.Count(out, `"level":"error"`)
+
+	// 期望约 20 条 info，上限留出足够余量避免偶发失败
+	if infos >= n/10 {
+		t.Errorf("kept %d of %d info lines at 1%% sampling, want far fewer", infos, n)
+	}
+	if warns != n {
+		t.Errorf("kept %d of %d warn lines, want all of them", warns, n)
+	}
+	if errs != n {
+		t.Errorf("kept %d of %d error lines, want all of them", errs, n)
+	}
+}
+
+func TestZeroSampleRateKeepsEveryInfoLine(t *testing.T) {
+	resetLogger(t)
+	var buf bytes.Buffer
+	InitWithOptions("order-service", LoggerOptions{Level: zerolog.InfoLevel, Writer: &buf})
+
+	for i := 0; i < 100; i++ {
+		Logger.Info().Msg("order processed")
+	}
+	if infos := strings.Count(buf.String(), `"level":"info"`); infos != 100 {
+		t.Errorf("kept %d of 100 info lines without sampling, want all of them", infos)
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+// HeaderForceDebug 是开启单请求调试日志的 HTTP 头，值为 true/1 时生效
+const HeaderForceDebug = "X-Force-Debug"
+
+// ForceDebug 在请求带有 X-Force-Debug 头时为其开启调试日志，
+// 之后通过 logger.Ctx 获取的 logger 会输出 debug 级别的日志，而不需要把整个服务切换到 debug。
+// 需要跨服务传播时，应在上游设置 force-debug baggage。
+func ForceDebug(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if forced, _ := strconv.ParseBool(r.Header.Get(HeaderForceDebug)); forced {
+			r = r.WithContext(logger.WithForceDebug(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
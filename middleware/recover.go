@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/wangyingjie930/nexus-pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RecoverOptions 控制 panic 恢复的行为
+type RecoverOptions struct {
+	// RePanic 为 true 时，在记录日志和 Span 之后重新抛出 panic，便于在非生产环境中调试
+	RePanic bool
+}
+
+// Recover 从 handler 的 panic 中恢复，返回 500 而不是让整个进程崩溃。
+// panic 会连同堆栈一起通过 logger.Ctx 记录，并作为错误记录到当前 Span 上。
+// 它应放在 Tracing 之内(即 Tracing(Recover(handler)))，这样 panic 能关联到请求的 Span。
+func Recover(next http.Handler) http.Handler {
+	return RecoverWithOptions(RecoverOptions{})(next)
+}
+
+// RecoverWithOptions 返回一个使用指定选项的 Recover 中间件
+func RecoverWithOptions(opts RecoverOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				// http.ErrAbortHandler 是标准库约定的中止请求方式，保持原有语义
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				ctx := r.Context()
+				stack := string(debug.Stack())
+				err := fmt.Errorf("panic: %v", p)
+
+				span := trace.SpanFromContext(ctx)
+				span.RecordError(err, trace.WithAttributes(attribute.String("exception.stacktrace", stack)))
+				span.SetStatus(codes.Error, err.Error())
+
+				logger.Ctx(ctx).Error().
+					Err(err).
+					Str("method", r.Method).
+					Str("path", r.URL.Path).
+					Str("stack", stack).
+					Msg("❌ Recovered from panic in HTTP handler")
+
+				if opts.RePanic {
+					panic(p)
+				}
+				// 响应头已经写出时无法再修改状态码
+				if !rec.wroteHeader {
+					http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/wangyingjie930/nexus-pkg/idgen"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderRequestID 是传递请求 ID 的 HTTP 头
+const HeaderRequestID = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID 为每个请求分配一个请求 ID。
+// 如果上游已经通过 X-Request-ID 传入则沿用，否则使用 idgen 生成一个全局唯一、按时间有序的 ID。
+// 请求 ID 会写回响应头、存入请求 context，并记录到当前 Span 上。
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if id == "" {
+			id = idgen.New()
+		}
+		w.Header().Set(HeaderRequestID, id)
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("request.id", id))
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext 返回 RequestID 中间件存入 context 的请求 ID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,269 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+// adminTimeout 是管理类请求的默认超时
+const adminTimeout = 10 * time.Second
+
+type offsetKind int
+
+const (
+	offsetEarliest offsetKind = iota
+	offsetLatest
+	offsetTimestamp
+)
+
+// OffsetTarget 描述消费组 offset 需要重置到的位置
+type OffsetTarget struct {
+	kind offsetKind
+	ts   time.Time
+}
+
+var (
+	// OffsetEarliest 重置到每个分区最早的可用消息
+	OffsetEarliest = OffsetTarget{kind: offsetEarliest}
+	// OffsetLatest 重置到每个分区的末尾，跳过所有未消费的消息
+	OffsetLatest = OffsetTarget{kind: offsetLatest}
+)
+
+// OffsetAt 重置到每个分区中时间戳不早于 t 的第一条消息；没有这样的消息时重置到分区末尾
+func OffsetAt(t time.Time) OffsetTarget {
+	return OffsetTarget{kind: offsetTimestamp, ts: t}
+}
+
+func (t OffsetTarget) String() string {
+	switch t.kind {
+	case offsetEarliest:
+		return "earliest"
+	case offsetLatest:
+		return "latest"
+	default:
+		return t.ts.Format(time.RFC3339)
+	}
+}
+
+// ResetConsumerGroupOffset 将消费组在 topic 上的 offset 重置到 target。
+// 为避免破坏正在运行的消费组，只有在消费组没有活跃成员时才会执行，否则返回错误。
+func ResetConsumerGroupOffset(ctx context.Context, brokers []string, group, topic string, target OffsetTarget) error {
+	return ResetConsumerGroupOffsetWithSecurity(ctx, brokers, group, topic, target, SecurityConfig{})
+}
+
+// ResetConsumerGroupOffsetWithSecurity 与 ResetConsumerGroupOffset 相同，但使用 sec 中的 SASL/TLS 配置连接集群
+func ResetConsumerGroupOffsetWithSecurity(ctx context.Context, brokers []string, group, topic string, target OffsetTarget, sec SecurityConfig) error {
+	client := newAdminClient(brokers, sec)
+
+	// 1. 确认消费组当前没有活跃成员
+	groups, err := client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{group}})
+	if err != nil {
+		return fmt.Errorf("failed to describe consumer group '%s': %w", group, err)
+	}
+	for _, g := range groups.Groups {
+		if g.Error != nil {
+			return fmt.Errorf("failed to describe consumer group '%s': %w", group, g.Error)
+		}
+		if len(g.Members) > 0 {
+			return fmt.Errorf("consumer group '%s' has %d active members (state %s), stop all consumers before resetting offsets", group, len(g.Members), g.GroupState)
+		}
+	}
+
+	// 2. 计算每个分区的目标 offset
+	partitions, err := topicPartitions(ctx, client, topic)
+	if err != nil {
+		return err
+	}
+	offsets, err := resolveOffsets(ctx, client, topic, partitions, target)
+	if err != nil {
+		return err
+	}
+
+	// 3. 以"无代际"的方式直接提交 offset，这只在消费组为空时被 broker 接受
+	commits := make([]kafka.OffsetCommit, 0, len(offsets))
+	for partition, offset := range offsets {
+		commits = append(commits, kafka.OffsetCommit{Partition: partition, Offset: offset})
+	}
+	resp, err := client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      group,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit offsets for group '%s': %w", group, err)
+	}
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return fmt.Errorf("failed to commit offset for %s[%d]: %w", topic, p.Partition, p.Error)
+		}
+	}
+
+	logger.Logger.Info().Str("group", group).Str("topic", topic).Str("target", target.String()).
+		Any("offsets", offsets).Msg("consumer group offsets reset")
+	return nil
+}
+
+// topicPartitions 返回 topic 的全部分区号
+func topicPartitions(ctx context.Context, client *kafka.Client, topic string) ([]int, error) {
+	meta, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metadata for topic '%s': %w", topic, err)
+	}
+	for _, t := range meta.Topics {
+		if t.Name != topic {
+			continue
+		}
+		if t.Error != nil {
+			return nil, fmt.Errorf("failed to fetch metadata for topic '%s': %w", topic, t.Error)
+		}
+		partitions := make([]int, 0, len(t.Partitions))
+		for _, p := range t.Partitions {
+			partitions = append(partitions, p.ID)
+		}
+		return partitions, nil
+	}
+	return nil, fmt.Errorf("topic '%s' not found", topic)
+}
+
+// resolveOffsets 将 OffsetTarget 解析为每个分区的具体 offset
+func resolveOffsets(ctx context.Context, client *kafka.Client, topic string, partitions []int, target OffsetTarget) (map[int]int64, error) {
+	switch target.kind {
+	case offsetEarliest:
+		results, err := listOffsets(ctx, client, topic, partitions, kafka.FirstOffsetOf)
+		if err != nil {
+			return nil, err
+		}
+		offsets := make(map[int]int64, len(results))
+		for _, p := range results {
+			offsets[p.Partition] = p.FirstOffset
+		}
+		return offsets, nil
+	case offsetLatest:
+		return latestOffsets(ctx, client, topic, partitions)
+	}
+
+	// 时间戳之后没有消息时 broker 不会返回有效 offset，此时重置到分区末尾
+	offsets, err := latestOffsets(ctx, client, topic, partitions)
+	if err != nil {
+		return nil, err
+	}
+	results, err := listOffsets(ctx, client, topic, partitions, func(p int) kafka.OffsetRequest {
+		return kafka.TimeOffsetOf(p, target.ts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range results {
+		for o := range p.Offsets {
+			if o >= 0 && o < offsets[p.Partition] {
+				offsets[p.Partition] = o
+			}
+		}
+	}
+	return offsets, nil
+}
+
+// latestOffsets 返回每个分区的高水位(下一条待写入消息的 offset)
+func latestOffsets(ctx context.Context, client *kafka.Client, topic string, partitions []int) (map[int]int64, error) {
+	results, err := listOffsets(ctx, client, topic, partitions, kafka.LastOffsetOf)
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[int]int64, len(results))
+	for _, p := range results {
+		offsets[p.Partition] = p.LastOffset
+	}
+	return offsets, nil
+}
+
+// listOffsets 为每个分区发送一个 ListOffsets 请求并检查分区级错误
+func listOffsets(ctx context.Context, client *kafka.Client, topic string, partitions []int, request func(partition int) kafka.OffsetRequest) ([]kafka.PartitionOffsets, error) {
+	requests := make([]kafka.OffsetRequest, 0, len(partitions))
+	for _, p := range partitions {
+		requests = append(requests, request(p))
+	}
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets for topic '%s': %w", topic, err)
+	}
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to list offsets for %s[%d]: %w", topic, p.Partition, p.Error)
+		}
+	}
+	return resp.Topics[topic], nil
+}
+
+// ConsumerLag 返回消费组在 topic 各分区上的积压量(高水位 - 已提交 offset)。
+// 尚未提交过 offset 的分区按从最早消息开始消费计算积压，即高水位 - 最早 offset。
+// 适合在后台任务中定期调用，将结果发布到监控指标。
+func ConsumerLag(ctx context.Context, brokers []string, groupID, topic string) (map[int]int64, error) {
+	return ConsumerLagWithSecurity(ctx, brokers, groupID, topic, SecurityConfig{})
+}
+
+// ConsumerLagWithSecurity 与 ConsumerLag 相同，但使用 sec 中的 SASL/TLS 配置连接集群
+func ConsumerLagWithSecurity(ctx context.Context, brokers []string, groupID, topic string, sec SecurityConfig) (map[int]int64, error) {
+	client := newAdminClient(brokers, sec)
+
+	partitions, err := topicPartitions(ctx, client, topic)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := latestOffsets(ctx, client, topic, partitions)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: groupID,
+		Topics:  map[string][]int{topic: partitions},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets for group '%s': %w", groupID, err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets for group '%s': %w", groupID, resp.Error)
+	}
+
+	committed := make(map[int]int64, len(partitions))
+	var uncommitted []int
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return nil, fmt.Errorf("failed to fetch committed offset for %s[%d]: %w", topic, p.Partition, p.Error)
+		}
+		if p.CommittedOffset < 0 {
+			uncommitted = append(uncommitted, p.Partition)
+			continue
+		}
+		committed[p.Partition] = p.CommittedOffset
+	}
+	for _, partition := range partitions {
+		if _, ok := committed[partition]; !ok && !slices.Contains(uncommitted, partition) {
+			uncommitted = append(uncommitted, partition)
+		}
+	}
+
+	// 没有提交记录的分区以最早 offset 作为起点
+	if len(uncommitted) > 0 {
+		results, err := listOffsets(ctx, client, topic, uncommitted, kafka.FirstOffsetOf)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range results {
+			committed[p.Partition] = p.FirstOffset
+		}
+	}
+
+	lag := make(map[int]int64, len(partitions))
+	for _, partition := range partitions {
+		lag[partition] = max(latest[partition]-committed[partition], 0)
+	}
+	return lag, nil
+}
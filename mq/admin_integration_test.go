@@ -0,0 +1,145 @@
+//go:build integration
+
+package mq
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// 集成测试需要一个可用的 Kafka 集群，通过 NEXUS_KAFKA_BROKERS(逗号分隔)指定:
+//
+//	NEXUS_KAFKA_BROKERS=localhost:9092 go test -tags integration ./mq -run Integration
+func integrationBrokers(t *testing.T) []string {
+	t.Helper()
+	raw := os.Getenv("NEXUS_KAFKA_BROKERS")
+	if raw == "" {
+		t.Skip("NEXUS_KAFKA_BROKERS not set, skipping Kafka integration test")
+	}
+	return strings.Split(raw, ",")
+}
+
+// createTestTopic 创建一个单分区的临时主题，测试结束后删除
+func createTestTopic(t *testing.T, ctx context.Context, brokers []string) string {
+	t.Helper()
+	topic := fmt.Sprintf("nexus-pkg.it.reset.%d", time.Now().UnixNano())
+	client := &kafka.Client{Addr: kafka.TCP(brokers...), Timeout: adminTimeout}
+	resp, err := client.CreateTopics(ctx, &kafka.CreateTopicsRequest{
+		Topics: []kafka.TopicConfig{{Topic: topic, NumPartitions: 1, ReplicationFactor: 1}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create topic: %v", err)
+	}
+	if err := resp.Errors[topic]; err != nil {
+		t.Fatalf("failed to create topic %s: %v", topic, err)
+	}
+	t.Cleanup(func() {
+		_, _ = client.DeleteTopics(context.Background(), &kafka.DeleteTopicsRequest{Topics: []string{topic}})
+	})
+	return topic
+}
+
+// consumeAll 以消费组身份消费 n 条消息并提交，关闭 reader 后消费组不再有活跃成员
+func consumeAll(t *testing.T, ctx context.Context, brokers []string, topic, group string, n int) {
+	t.Helper()
+	reader := NewKafkaReader(brokers, topic, group)
+	defer reader.Close()
+	for i := 0; i < n; i++ {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			t.Fatalf("failed to fetch message %d: %v", i, err)
+		}
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			t.Fatalf("failed to commit message %d: %v", i, err)
+		}
+	}
+}
+
+func lagOf(t *testing.T, ctx context.Context, brokers []string, group, topic string) int64 {
+	t.Helper()
+	lag, err := ConsumerLag(ctx, brokers, group, topic)
+	if err != nil {
+		t.Fatalf("ConsumerLag returned error: %v", err)
+	}
+	return lag[0]
+}
+
+func TestIntegrationResetConsumerGroupOffset(t *testing.T) {
+	brokers := integrationBrokers(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	topic := createTestTopic(t, ctx, brokers)
+	group := topic + ".group"
+
+	writer := NewKafkaSyncWriter(brokers, topic)
+	defer writer.Close()
+	msgs := make([]kafka.Message, 5)
+	for i := range msgs {
+		msgs[i] = kafka.Message{Value: []byte(fmt.Sprintf("message-%d", i))}
+	}
+	if err := writer.WriteMessages(ctx, msgs...); err != nil {
+		t.Fatalf("failed to write messages: %v", err)
+	}
+
+	consumeAll(t, ctx, brokers, topic, group, len(msgs))
+	if lag := lagOf(t, ctx, brokers, group, topic); lag != 0 {
+		t.Fatalf("lag after consuming everything = %d, want 0", lag)
+	}
+
+	// 重置到最早: 全部消息重新变为积压
+	if err := ResetConsumerGroupOffset(ctx, brokers, group, topic, OffsetEarliest); err != nil {
+		t.Fatalf("reset to earliest failed: %v", err)
+	}
+	if lag := lagOf(t, ctx, brokers, group, topic); lag != int64(len(msgs)) {
+		t.Errorf("lag after reset to earliest = %d, want %d", lag, len(msgs))
+	}
+
+	// 重置到最新: 积压清零
+	if err := ResetConsumerGroupOffset(ctx, brokers, group, topic, OffsetLatest); err != nil {
+		t.Fatalf("reset to latest failed: %v", err)
+	}
+	if lag := lagOf(t, ctx, brokers, group, topic); lag != 0 {
+		t.Errorf("lag after reset to latest = %d, want 0", lag)
+	}
+
+	// 重置到未来的时间点: 没有更晚的消息，应落在分区末尾
+	if err := ResetConsumerGroupOffset(ctx, brokers, group, topic, OffsetAt(time.Now().Add(time.Hour))); err != nil {
+		t.Fatalf("reset to timestamp failed: %v", err)
+	}
+	if lag := lagOf(t, ctx, brokers, group, topic); lag != 0 {
+		t.Errorf("lag after reset to a future timestamp = %d, want 0", lag)
+	}
+}
+
+func TestIntegrationResetRejectsActiveGroup(t *testing.T) {
+	brokers := integrationBrokers(t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	topic := createTestTopic(t, ctx, brokers)
+	group := topic + ".group"
+
+	writer := NewKafkaSyncWriter(brokers, topic)
+	defer writer.Close()
+	if err := writer.WriteMessages(ctx, kafka.Message{Value: []byte("hello")}); err != nil {
+		t.Fatalf("failed to write message: %v", err)
+	}
+
+	// 保持一个活跃成员: 读到消息说明 reader 已经加入消费组
+	reader := NewKafkaReader(brokers, topic, group)
+	defer reader.Close()
+	if _, err := reader.FetchMessage(ctx); err != nil {
+		t.Fatalf("failed to fetch message: %v", err)
+	}
+
+	if err := ResetConsumerGroupOffset(ctx, brokers, group, topic, OffsetEarliest); err == nil {
+		t.Fatal("reset succeeded while the group had an active member, want an error")
+	}
+}
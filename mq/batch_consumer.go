@@ -0,0 +1,121 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+// MessageHandler 处理单条 Kafka 消息
+type MessageHandler func(ctx context.Context, msg kafka.Message) error
+
+// BatchConfig 控制批量确认的行为
+type BatchConfig struct {
+	// Size 每批最多处理的消息数，默认 100
+	Size int
+	// Window 从收到一批中第一条消息开始，最长等待凑批的时间，默认 1 秒
+	Window time.Duration
+}
+
+// BatchConsumer 以批量确认模式消费消息:
+// 逐条调用 handler 处理一批消息后，只提交一次该批次的最大 offset，在吞吐量和丢失窗口之间取得平衡。
+// 当批次中某条消息处理失败时，只提交到最后一条成功的消息，然后返回该错误，
+// 由调用方(例如 Application.AddTask)决定重建消费者或关停服务，重建后会从失败的消息开始重新消费。
+// 如果希望失败消息进入重试/死信流程而不中断消费，应在 handler 中调用 FailureHandler 并返回 nil。
+type BatchConsumer struct {
+	reader  messageFetcher
+	cfg     BatchConfig
+	handler MessageHandler
+}
+
+// messageFetcher 是 BatchConsumer 使用的 *kafka.Reader 方法子集，便于在测试中替换
+type messageFetcher interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// NewBatchConsumer 创建一个批量确认消费者，reader 必须配置了 GroupID
+func NewBatchConsumer(reader *kafka.Reader, cfg BatchConfig, handler MessageHandler) *BatchConsumer {
+	if cfg.Size <= 0 {
+		cfg.Size = 100
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = time.Second
+	}
+	return &BatchConsumer{
+		reader:  reader,
+		cfg:     cfg,
+		handler: handler,
+	}
+}
+
+// Run 持续消费直到 ctx 被取消或 handler 返回错误
+func (c *BatchConsumer) Run(ctx context.Context) error {
+	for {
+		batch, err := c.fetchBatch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch messages: %w", err)
+		}
+		if err := c.processBatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+}
+
+// fetchBatch 阻塞等待第一条消息，然后在窗口期内尽量凑满一批
+func (c *BatchConsumer) fetchBatch(ctx context.Context) ([]kafka.Message, error) {
+	first, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch := make([]kafka.Message, 0, c.cfg.Size)
+	batch = append(batch, first)
+
+	windowCtx, cancel := context.WithTimeout(ctx, c.cfg.Window)
+	defer cancel()
+	for len(batch) < c.cfg.Size {
+		msg, err := c.reader.FetchMessage(windowCtx)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				break // 窗口结束，处理已经凑到的消息
+			}
+			// 已拉取但未提交的消息会在消费者重建后重新投递
+			return nil, err
+		}
+		batch = append(batch, msg)
+	}
+	return batch, nil
+}
+
+// processBatch 逐条处理消息，并只提交一次成功处理的前缀
+func (c *BatchConsumer) processBatch(ctx context.Context, batch []kafka.Message) error {
+	processed := 0
+	var handlerErr error
+	for _, msg := range batch {
+		msgCtx := ExtractTraceContext(ctx, msg.Headers)
+		if err := c.handler(msgCtx, msg); err != nil {
+			handlerErr = fmt.Errorf("handler failed at %s[%d]@%d: %w", msg.Topic, msg.Partition, msg.Offset, err)
+			break
+		}
+		processed++
+	}
+
+	if processed > 0 {
+		// CommitMessages 会为每个分区提交该前缀中的最大 offset
+		if err := c.reader.CommitMessages(ctx, batch[:processed]...); err != nil {
+			return fmt.Errorf("failed to commit batch: %w", err)
+		}
+	}
+	if handlerErr != nil {
+		logger.Ctx(ctx).Error().Err(handlerErr).Int("committed", processed).Int("batch", len(batch)).Msg("batch processing stopped on handler error")
+		return handlerErr
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeFetcher 依次返回预置的消息，消息耗尽后阻塞到 ctx 结束，并记录提交的消息
+type fakeFetcher struct {
+	mu        sync.Mutex
+	pending   []kafka.Message
+	committed []kafka.Message
+}
+
+func (f *fakeFetcher) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	f.mu.Lock()
+	if len(f.pending) > 0 {
+		msg := f.pending[0]
+		f.pending = f.pending[1:]
+		f.mu.Unlock()
+		return msg, nil
+	}
+	f.mu.Unlock()
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (f *fakeFetcher) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.committed = append(f.committed, msgs...)
+	return nil
+}
+
+func (f *fakeFetcher) committedOffsets() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	offsets := make([]int64, len(f.committed))
+	for i, msg := range f.committed {
+		offsets[i] = msg.Offset
+	}
+	return offsets
+}
+
+func testMessages(n int) []kafka.Message {
+	msgs := make([]kafka.Message, n)
+	for i := range msgs {
+		msgs[i] = kafka.Message{Topic: "orders", Partition: 0, Offset: int64(i)}
+	}
+	return msgs
+}
+
+func TestBatchConsumerCommitsWholeBatch(t *testing.T) {
+	fetcher := &fakeFetcher{pending: testMessages(3)}
+	var handled []int64
+	c := &BatchConsumer{
+		reader: fetcher,
+		cfg:    BatchConfig{Size: 3, Window: 50 * time.Millisecond},
+		handler: func(_ context.Context, msg kafka.Message) error {
+			handled = append(handled, msg.Offset)
+			return nil
+		},
+	}
+
+	batch, err := c.fetchBatch(context.Background())
+	if err != nil {
+		t.Fatalf("fetchBatch returned error: %v", err)
+	}
+	if err := c.processBatch(context.Background(), batch); err != nil {
+		t.Fatalf("processBatch returned error: %v", err)
+	}
+
+	if len(handled) != 3 {
+		t.Errorf("handled %v, want offsets 0-2", handled)
+	}
+	if got := fetcher.committedOffsets(); len(got) != 3 || got[2] != 2 {
+		t.Errorf("committed offsets = %v, want [0 1 2]", got)
+	}
+}
+
+func TestBatchConsumerCommitsOnlySuccessfulPrefix(t *testing.T) {
+	fetcher := &fakeFetcher{}
+	handlerErr := errors.New("payment service unavailable")
+	c := &BatchConsumer{
+		reader: fetcher,
+		cfg:    BatchConfig{Size: 5, Window: 50 * time.Millisecond},
+		handler: func(_ context.Context, msg kafka.Message) error {
+			if msg.Offset == 2 {
+				return handlerErr
+			}
+			return nil
+		},
+	}
+
+	err := c.processBatch(context.Background(), testMessages(5))
+	if !errors.Is(err, handlerErr) {
+		t.Fatalf("processBatch error = %v, want it to wrap %v", err, handlerErr)
+	}
+	// 只提交失败消息之前的前缀，失败消息及其之后的消息会在消费者重建后重新消费
+	got := fetcher.committedOffsets()
+	if len(got) != 2 || got[0] != 0 || got[1] != 1 {
+		t.Errorf("committed offsets = %v, want [0 1]", got)
+	}
+}
+
+func TestBatchConsumerCommitsNothingWhenFirstMessageFails(t *testing.T) {
+	fetcher := &fakeFetcher{}
+	c := &BatchConsumer{
+		reader: fetcher,
+		cfg:    BatchConfig{Size: 3, Window: 50 * time.Millisecond},
+		handler: func(context.Context, kafka.Message) error {
+			return errors.New("boom")
+		},
+	}
+
+	if err := c.processBatch(context.Background(), testMessages(3)); err == nil {
+		t.Fatal("processBatch returned nil, want the handler error")
+	}
+	if got := fetcher.committedOffsets(); len(got) != 0 {
+		t.Errorf("committed offsets = %v, want none", got)
+	}
+}
+
+func TestBatchConsumerWindowFlushesPartialBatch(t *testing.T) {
+	fetcher := &fakeFetcher{pending: testMessages(2)}
+	c := &BatchConsumer{reader: fetcher, cfg: BatchConfig{Size: 10, Window: 20 * time.Millisecond}}
+
+	start := time.Now()
+	batch, err := c.fetchBatch(context.Background())
+	if err != nil {
+		t.Fatalf("fetchBatch returned error: %v", err)
+	}
+	if len(batch) != 2 {
+		t.Errorf("got a batch of %d messages, want 2", len(batch))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("fetchBatch took %v, want it to return after the window", elapsed)
+	}
+}
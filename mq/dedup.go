@@ -0,0 +1,147 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/segmentio/kafka-go"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+	"github.com/wangyingjie930/nexus-pkg/redis"
+)
+
+const (
+	// 去重记录的默认保留时长
+	defaultDedupWindow = 24 * time.Hour
+	// 处理中占位的默认租期
+	defaultDedupLease = 5 * time.Minute
+)
+
+// 去重 key 的取值
+const (
+	dedupProcessing = "processing"
+	dedupDone       = "done"
+)
+
+// ErrMessageInFlight 表示同一条消息正在被另一个消费者处理(例如再均衡之后旧的消费者仍在处理)。
+// 此时不能把消息当作重复消息丢弃，否则对方处理失败后消息就丢失了，应交给调用方稍后重试。
+var ErrMessageInFlight = errors.New("mq: message is being processed by another consumer")
+
+// dedupStore 是 Deduplicator 使用的 Redis 操作子集，便于在测试中替换
+type dedupStore interface {
+	// SetNX 在 key 不存在时写入 value 并返回 true
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Get 读取 key 的值，key 不存在时返回空字符串
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+// redisDedupStore 基于 redis.Client 实现 dedupStore
+type redisDedupStore struct {
+	client *redis.Client
+}
+
+func (s redisDedupStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return s.client.GetClient().SetNX(ctx, key, value, ttl).Result()
+}
+
+func (s redisDedupStore) Get(ctx context.Context, key string) (string, error) {
+	value, err := s.client.GetClient().Get(ctx, key).Result()
+	if errors.Is(err, goredis.Nil) {
+		return "", nil
+	}
+	return value, err
+}
+
+func (s redisDedupStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return s.client.GetClient().Set(ctx, key, value, ttl).Err()
+}
+
+func (s redisDedupStore) Del(ctx context.Context, key string) error {
+	return s.client.GetClient().Del(ctx, key).Err()
+}
+
+// Deduplicator 基于 Redis 记录已处理的消息 ID(取自 message-id Header)，跳过重复投递的消息。
+// outbox 转发与失败重试都可能产生重复消息，配合它可以在应用层获得近似 exactly-once 的处理语义。
+type Deduplicator struct {
+	store     dedupStore
+	keyPrefix string
+	window    time.Duration
+	lease     time.Duration
+}
+
+// NewDeduplicator 创建一个去重器，window 为已处理 ID 的保留时长，<= 0 时使用默认的 24 小时。
+// window 应覆盖消息可能被重复投递的最长时间(包括最长的重试延迟)。处理中占位的租期为默认的 5 分钟。
+func NewDeduplicator(client *redis.Client, window time.Duration) *Deduplicator {
+	return NewDeduplicatorWithLease(client, window, defaultDedupLease)
+}
+
+// NewDeduplicatorWithLease 与 NewDeduplicator 相同，但可以指定处理中占位的租期，<= 0 时使用默认的 5 分钟。
+// 租期应大于 handler 的最长处理时间: 进程在处理中途崩溃时，占位最多保留一个租期，之后重新投递的消息可以再次处理。
+func NewDeduplicatorWithLease(client *redis.Client, window, lease time.Duration) *Deduplicator {
+	return newDeduplicator(redisDedupStore{client: client}, window, lease)
+}
+
+func newDeduplicator(store dedupStore, window, lease time.Duration) *Deduplicator {
+	if window <= 0 {
+		window = defaultDedupWindow
+	}
+	if lease <= 0 {
+		lease = defaultDedupLease
+	}
+	return &Deduplicator{
+		store:     store,
+		keyPrefix: "mq:dedup:",
+		window:    window,
+		lease:     lease,
+	}
+}
+
+// Wrap 为 handler 增加去重逻辑:
+// 处理前以 SET NX 占位消息 ID，占位只保留一个较短的租期；handler 成功后才把记录延长到整个去重窗口。
+// 已处理完成的消息直接跳过；另一个消费者正在处理的消息返回 ErrMessageInFlight，由调用方稍后重试；
+// handler 返回错误时删除占位，使消息在重新投递时可以再次处理。
+// 进程在处理中途崩溃时占位会在租期到期后自动释放，因此消息至少会被成功处理一次。
+// 没有 message-id Header 的消息不做去重。Redis 不可用时返回错误，由调用方决定是否重试。
+func (d *Deduplicator) Wrap(handler MessageHandler) MessageHandler {
+	return func(ctx context.Context, msg kafka.Message) error {
+		id, ok := GetHeader(msg.Headers, HeaderMessageID)
+		if !ok || id == "" {
+			return handler(ctx, msg)
+		}
+
+		key := d.keyPrefix + msg.Topic + ":" + id
+		claimed, err := d.store.SetNX(ctx, key, dedupProcessing, d.lease)
+		if err != nil {
+			return fmt.Errorf("failed to check message %s for duplicates: %w", id, err)
+		}
+		if !claimed {
+			state, err := d.store.Get(ctx, key)
+			if err != nil {
+				return fmt.Errorf("failed to check message %s for duplicates: %w", id, err)
+			}
+			if state == dedupDone {
+				logger.Ctx(ctx).Info().Str("messageId", id).Str("topic", msg.Topic).Msg("duplicate message skipped")
+				return nil
+			}
+			// 仍在处理中，或占位恰好在两次请求之间过期
+			return fmt.Errorf("message %s: %w", id, ErrMessageInFlight)
+		}
+
+		if err := handler(ctx, msg); err != nil {
+			if delErr := d.store.Del(context.WithoutCancel(ctx), key); delErr != nil {
+				logger.Ctx(ctx).Warn().Err(delErr).Str("messageId", id).Msg("⚠️ Failed to release dedup key after handler error")
+			}
+			return err
+		}
+
+		// 消息已经处理成功，记录失败只会让占位在租期到期后释放，最坏情况下重复处理一次，因此不返回错误
+		if err := d.store.Set(context.WithoutCancel(ctx), key, dedupDone, d.window); err != nil {
+			logger.Ctx(ctx).Warn().Err(err).Str("messageId", id).Msg("⚠️ Failed to mark message as processed")
+		}
+		return nil
+	}
+}
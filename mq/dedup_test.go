@@ -0,0 +1,199 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// memoryDedupStore 是带过期时间的内存 dedupStore，时间由 now 控制
+type memoryDedupStore struct {
+	mu      sync.Mutex
+	now     time.Time
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newMemoryDedupStore() *memoryDedupStore {
+	return &memoryDedupStore{
+		now:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		values:  map[string]string{},
+		expires: map[string]time.Time{},
+	}
+}
+
+// advance 推进时间，模拟 key 过期
+func (s *memoryDedupStore) advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+// lookupLocked 返回未过期的值，调用方需持有 mu
+func (s *memoryDedupStore) lookupLocked(key string) (string, bool) {
+	v, ok := s.values[key]
+	if !ok || !s.now.Before(s.expires[key]) {
+		return "", false
+	}
+	return v, true
+}
+
+func (s *memoryDedupStore) SetNX(_ context.Context, key, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookupLocked(key); ok {
+		return false, nil
+	}
+	s.values[key] = value
+	s.expires[key] = s.now.Add(ttl)
+	return true, nil
+}
+
+func (s *memoryDedupStore) Get(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.lookupLocked(key); ok {
+		return v, nil
+	}
+	return "", nil
+}
+
+func (s *memoryDedupStore) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	s.expires[key] = s.now.Add(ttl)
+	return nil
+}
+
+func (s *memoryDedupStore) Del(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+	delete(s.expires, key)
+	return nil
+}
+
+func dedupMessage(id string) kafka.Message {
+	return kafka.Message{Topic: "orders", Headers: []kafka.Header{{Key: HeaderMessageID, Value: []byte(id)}}}
+}
+
+func TestDeduplicatorSkipsProcessedMessage(t *testing.T) {
+	d := newDeduplicator(newMemoryDedupStore(), time.Hour, time.Minute)
+	calls := 0
+	handler := d.Wrap(func(context.Context, kafka.Message) error {
+		calls++
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := handler(context.Background(), dedupMessage("msg-1")); err != nil {
+			t.Fatalf("delivery %d returned error: %v", i, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestDeduplicatorProcessedRecordOutlivesLease(t *testing.T) {
+	store := newMemoryDedupStore()
+	d := newDeduplicator(store, time.Hour, time.Minute)
+	calls := 0
+	handler := d.Wrap(func(context.Context, kafka.Message) error {
+		calls++
+		return nil
+	})
+
+	_ = handler(context.Background(), dedupMessage("msg-1"))
+	// 成功后记录应延长到整个去重窗口，而不是在租期到期时消失
+	store.advance(30 * time.Minute)
+	_ = handler(context.Background(), dedupMessage("msg-1"))
+	if calls != 1 {
+		t.Errorf("handler called %d times within the dedup window, want 1", calls)
+	}
+}
+
+func TestDeduplicatorReprocessesAfterCrashMidHandler(t *testing.T) {
+	store := newMemoryDedupStore()
+	d := newDeduplicator(store, time.Hour, time.Minute)
+
+	// 第一次投递: 占位成功后进程在 handler 中途崩溃，既没有删除占位也没有标记完成
+	crashed := d.Wrap(func(context.Context, kafka.Message) error {
+		runtime.Goexit()
+		return nil
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = crashed(context.Background(), dedupMessage("msg-1"))
+	}()
+	<-done
+
+	var processed int
+	handler := d.Wrap(func(context.Context, kafka.Message) error {
+		processed++
+		return nil
+	})
+
+	// 租期内重新投递: 不能当作重复消息丢弃，而是交给调用方重试
+	err := handler(context.Background(), dedupMessage("msg-1"))
+	if !errors.Is(err, ErrMessageInFlight) {
+		t.Fatalf("redelivery during the lease returned %v, want ErrMessageInFlight", err)
+	}
+
+	// 租期过后重新投递: 消息必须被再次处理
+	store.advance(2 * time.Minute)
+	if err := handler(context.Background(), dedupMessage("msg-1")); err != nil {
+		t.Fatalf("redelivery after the lease returned error: %v", err)
+	}
+	if processed != 1 {
+		t.Errorf("redelivered message processed %d times, want 1", processed)
+	}
+}
+
+func TestDeduplicatorReleasesClaimOnHandlerError(t *testing.T) {
+	d := newDeduplicator(newMemoryDedupStore(), time.Hour, time.Minute)
+	handlerErr := errors.New("inventory service unavailable")
+	fail := true
+	calls := 0
+	handler := d.Wrap(func(context.Context, kafka.Message) error {
+		calls++
+		if fail {
+			return handlerErr
+		}
+		return nil
+	})
+
+	if err := handler(context.Background(), dedupMessage("msg-1")); !errors.Is(err, handlerErr) {
+		t.Fatalf("first delivery returned %v, want %v", err, handlerErr)
+	}
+	fail = false
+	if err := handler(context.Background(), dedupMessage("msg-1")); err != nil {
+		t.Fatalf("retry returned error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
+
+func TestDeduplicatorPassesThroughMessagesWithoutID(t *testing.T) {
+	d := newDeduplicator(newMemoryDedupStore(), time.Hour, time.Minute)
+	calls := 0
+	handler := d.Wrap(func(context.Context, kafka.Message) error {
+		calls++
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		_ = handler(context.Background(), kafka.Message{Topic: "orders"})
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
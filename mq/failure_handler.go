@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"unicode/utf8"
 )
 
 const (
@@ -22,8 +23,12 @@ const (
 	HeaderExceptionMessage    = "dlt-exception-message"
 	HeaderExceptionStacktrace = "dlt-exception-stacktrace"
 	HeaderRetryCount          = "retry-count"
+	HeaderRetryDelay          = "retry-delay-sec" // 重试消息需要延迟的秒数，由 RetryConsumer 读取
 )
 
+// defaultMaxHeaderBytes 是异常信息类 Header 的默认长度上限
+const defaultMaxHeaderBytes = 4096
+
 type ResilienceConfig struct {
 	Enabled             bool
 	RetryDelays         []int
@@ -31,34 +36,88 @@ type ResilienceConfig struct {
 	DltTopicTemplate    string
 	retryableExceptions map[string]struct{}
 	RetryableExceptions []string
+	// MaxHeaderBytes 限制异常信息与堆栈 Header 的字节数，超出部分被截断，<= 0 时使用默认值 4096。
+	// 过大的 Header 可能超过 broker 的消息大小限制，导致整条失败消息写入失败。
+	MaxHeaderBytes int
 }
 
 type FailureHandler struct {
 	brokers []string
-	config  ResilienceConfig
-	tracer  trace.Tracer
-	writers map[string]*kafka.Writer
-	mu      sync.Mutex
+	// 写入重试/死信主题时使用的 SASL/TLS 配置
+	security SecurityConfig
+	config   ResilienceConfig
+	// 按消费者名或源主题覆盖的策略，见 NewFailureHandlerWithPolicies
+	policies map[string]ResilienceConfig
+	tracer   trace.Tracer
+	writers  map[string]*kafka.Writer
+	mu       sync.Mutex
 }
 
 func NewFailureHandler(brokers []string, config ResilienceConfig, tracer trace.Tracer) *FailureHandler {
+	return NewFailureHandlerWithPolicies(brokers, config, nil, tracer)
+}
+
+// NewSecureFailureHandler 与 NewFailureHandler 相同，但写入重试/死信主题时使用 sec 中的 SASL/TLS 配置
+func NewSecureFailureHandler(brokers []string, config ResilienceConfig, tracer trace.Tracer, sec SecurityConfig) *FailureHandler {
+	return NewSecureFailureHandlerWithPolicies(brokers, config, nil, tracer, sec)
+}
+
+// NewValidatedFailureHandler 与 NewFailureHandler 相同，但会先校验 broker 列表
+func NewValidatedFailureHandler(brokers []string, config ResilienceConfig, tracer trace.Tracer) (*FailureHandler, error) {
+	if err := ValidateBrokers(brokers); err != nil {
+		return nil, err
+	}
+	return NewFailureHandler(brokers, config, tracer), nil
+}
+
+// NewFailureHandlerWithPolicies 创建一个支持多套策略的 FailureHandler。
+// policies 的 key 为消费者名或源主题: Handle 时先按 HandleFor 传入的消费者名查找，再按消息的源主题查找，
+// 都没有命中时使用 config 作为默认策略。
+func NewFailureHandlerWithPolicies(brokers []string, config ResilienceConfig, policies map[string]ResilienceConfig, tracer trace.Tracer) *FailureHandler {
+	return NewSecureFailureHandlerWithPolicies(brokers, config, policies, tracer, SecurityConfig{})
+}
+
+// NewSecureFailureHandlerWithPolicies 与 NewFailureHandlerWithPolicies 相同，但写入重试/死信主题时使用 sec 中的 SASL/TLS 配置
+func NewSecureFailureHandlerWithPolicies(brokers []string, config ResilienceConfig, policies map[string]ResilienceConfig, tracer trace.Tracer, sec SecurityConfig) *FailureHandler {
+	compiled := make(map[string]ResilienceConfig, len(policies))
+	for name, policy := range policies {
+		compiled[name] = compileResilience(policy)
+	}
+
+	return &FailureHandler{
+		brokers:  brokers,
+		security: sec,
+		config:   compileResilience(config),
+		policies: compiled,
+		tracer:   tracer,
+		writers:  make(map[string]*kafka.Writer),
+	}
+}
+
+// compileResilience 将可重试异常列表转换为集合，便于 Handle 时查找
+func compileResilience(config ResilienceConfig) ResilienceConfig {
 	retryableSet := make(map[string]struct{})
 	for _, ex := range config.RetryableExceptions {
 		retryableSet[ex] = struct{}{}
 	}
 	config.RetryableExceptions = nil
 	config.retryableExceptions = retryableSet
-
-	return &FailureHandler{
-		brokers: brokers,
-		config:  config,
-		tracer:  tracer,
-		writers: make(map[string]*kafka.Writer),
+	if config.MaxHeaderBytes <= 0 {
+		config.MaxHeaderBytes = defaultMaxHeaderBytes
 	}
+	return config
 }
 
+// Handle 按消息源主题对应的策略(没有时使用默认策略)处理一次消费失败
 func (h *FailureHandler) Handle(ctx context.Context, originalMsg kafka.Message, err error) {
-	if !h.config.Enabled {
+	h.HandleFor(ctx, "", originalMsg, err)
+}
+
+// HandleFor 与 Handle 相同，但优先使用 consumer 对应的策略，consumer 为空时等价于 Handle
+func (h *FailureHandler) HandleFor(ctx context.Context, consumer string, originalMsg kafka.Message, err error) {
+	baseTopic := h.sourceTopic(originalMsg)
+	config := h.policyFor(consumer, baseTopic)
+	if !config.Enabled {
 		return // Resilience is disabled
 	}
 
@@ -67,38 +126,40 @@ func (h *FailureHandler) Handle(ctx context.Context, originalMsg kafka.Message,
 
 	retryCount, _ := strconv.Atoi(getHeaderValue(originalMsg.Headers, HeaderRetryCount))
 
-	isRetryable := h.isRetryable(err)
-	maxRetries := len(h.config.RetryDelays)
+	retryable := isRetryable(config, err)
+	maxRetries := len(config.RetryDelays)
 
-	var targetTopic string
-	baseTopic := getHeaderValue(originalMsg.Headers, HeaderOriginalTopic)
-	if baseTopic == "" {
-		baseTopic = originalMsg.Topic
-	}
+	var targetTopic, delayHeader string
 
-	if isRetryable && retryCount < maxRetries {
+	if retryable && retryCount < maxRetries {
 		// --- Handle Retry ---
-		delay := h.config.RetryDelays[retryCount]
+		delay := config.RetryDelays[retryCount]
+		delayHeader = strconv.Itoa(delay)
 		targetTopic = strings.NewReplacer(
 			"{topic}", baseTopic,
 			"{delaySec}", strconv.Itoa(delay),
-		).Replace(h.config.RetryTopicTemplate)
+		).Replace(config.RetryTopicTemplate)
 		span.SetAttributes(
 			attribute.String("originalMsg.Topic", baseTopic),
 			attribute.String("failure.action", "RETRY"),
 			attribute.String("failure.target_topic", targetTopic),
 		)
 		retryCount++
+		retriesTotal.WithLabelValues(baseTopic).Inc()
 	} else {
 		// --- Handle DLT ---
 		targetTopic = strings.NewReplacer(
 			"{topic}", baseTopic,
-		).Replace(h.config.DltTopicTemplate)
+		).Replace(config.DltTopicTemplate)
 		span.SetAttributes(attribute.String("failure.action", "DLT"), attribute.String("failure.target_topic", targetTopic))
+		dltTotal.WithLabelValues(baseTopic).Inc()
 	}
 
 	// Enrich headers and publish
-	newMsg := h.prepareMessage(originalMsg, err, retryCount, baseTopic)
+	newMsg := h.prepareMessage(originalMsg, err, retryCount, baseTopic, config.MaxHeaderBytes)
+	if delayHeader != "" {
+		newMsg.Headers = append(newMsg.Headers, kafka.Header{Key: HeaderRetryDelay, Value: []byte(delayHeader)})
+	}
 
 	writer := h.getWriter(targetTopic)
 	logger.Ctx(ctx).Info().Any("targetTopic", targetTopic).Msg("failure.Writer")
@@ -106,8 +167,74 @@ func (h *FailureHandler) Handle(ctx context.Context, originalMsg kafka.Message,
 	if writeErr := writer.WriteMessages(ctx, newMsg); writeErr != nil {
 		span.RecordError(writeErr)
 		span.SetStatus(codes.Error, "Failed to publish to failure topic")
-		// Log critical error
+		failurePublishErrorsTotal.WithLabelValues(baseTopic).Inc()
+		// 最后的兜底: 完整记录消息内容，保证失败消息不会被静默丢弃，可以据此人工补发
+		logger.Ctx(ctx).Error().Err(writeErr).
+			Str("targetTopic", targetTopic).
+			Str("originalTopic", baseTopic).
+			Int("partition", originalMsg.Partition).
+			Int64("offset", originalMsg.Offset).
+			Bytes("key", newMsg.Key).
+			Bytes("payload", newMsg.Value).
+			Any("headers", headerMap(newMsg.Headers)).
+			Msg("❌ Failed to publish message to failure topic, message may be lost")
+	}
+}
+
+// policyFor 依次按消费者名、源主题查找策略，都没有时返回默认策略
+func (h *FailureHandler) policyFor(consumer, topic string) ResilienceConfig {
+	if policy, ok := h.policies[consumer]; ok && consumer != "" {
+		return policy
+	}
+	if policy, ok := h.policies[topic]; ok {
+		return policy
 	}
+	return h.config
+}
+
+// sourceTopic 返回消息真正的来源主题，保证整条重试链路上 {topic} 都展开为同一个原始主题:
+// 优先使用首次失败时写入的 dlt-original-topic 头；没有该头但消息直接读自重试/死信主题时，
+// 按各策略的主题模板反推出原始主题，避免把重试主题当作来源再套一层模板。
+func (h *FailureHandler) sourceTopic(msg kafka.Message) string {
+	if topic := getHeaderValue(msg.Headers, HeaderOriginalTopic); topic != "" {
+		return topic
+	}
+	if topic, ok := matchResilienceTopic(h.config, msg.Topic); ok {
+		return topic
+	}
+	for _, policy := range h.policies {
+		if topic, ok := matchResilienceTopic(policy, msg.Topic); ok {
+			return topic
+		}
+	}
+	return msg.Topic
+}
+
+// matchResilienceTopic 判断 topic 是否为 config 的某个重试主题或死信主题，是则返回对应的原始主题
+func matchResilienceTopic(config ResilienceConfig, topic string) (string, bool) {
+	templates := make([]string, 0, len(config.RetryDelays)+1)
+	for _, delay := range config.RetryDelays {
+		templates = append(templates, strings.ReplaceAll(config.RetryTopicTemplate, "{delaySec}", strconv.Itoa(delay)))
+	}
+	templates = append(templates, config.DltTopicTemplate)
+	for _, tmpl := range templates {
+		if source, ok := matchTopicTemplate(tmpl, topic); ok {
+			return source, true
+		}
+	}
+	return "", false
+}
+
+// matchTopicTemplate 判断 topic 是否由模板 tmpl 展开而来，是则返回 {topic} 对应的部分
+func matchTopicTemplate(tmpl, topic string) (string, bool) {
+	prefix, suffix, found := strings.Cut(tmpl, "{topic}")
+	if !found || prefix+suffix == "" {
+		return "", false
+	}
+	if len(topic) <= len(prefix)+len(suffix) || !strings.HasPrefix(topic, prefix) || !strings.HasSuffix(topic, suffix) {
+		return "", false
+	}
+	return topic[len(prefix) : len(topic)-len(suffix)], true
 }
 
 func (h *FailureHandler) getWriter(topic string) *kafka.Writer {
@@ -117,49 +244,78 @@ func (h *FailureHandler) getWriter(topic string) *kafka.Writer {
 		return writer
 	}
 	// Create writer on-demand
-	writer := NewKafkaWriter(h.brokers, topic)
+	// 使用同步 Writer，写入失败能够被 Handle 观察到并记录兜底日志；失败路径的流量很小，吞吐不是问题
+	writer := NewSecureKafkaSyncWriter(h.brokers, topic, h.security)
 	h.writers[topic] = writer
 	return writer
 }
 
-func (h *FailureHandler) prepareMessage(original kafka.Message, err error, retryCount int, baseTopic string) kafka.Message {
-	newHeaders := make([]kafka.Header, 0, len(original.Headers)+5)
+func (h *FailureHandler) prepareMessage(original kafka.Message, err error, retryCount int, baseTopic string, maxHeaderBytes int) kafka.Message {
+	newHeaders := make(KafkaHeaderCarrier, 0, len(original.Headers)+7)
 
 	for _, header := range original.Headers {
-		if header.Key != HeaderRetryCount {
+		if header.Key != HeaderRetryCount && header.Key != HeaderRetryDelay {
 			newHeaders = append(newHeaders, header)
 		}
 	}
 
 	// Add/Update mandatory headers
-	newHeaders = append(newHeaders, kafka.Header{Key: HeaderRetryCount, Value: []byte(strconv.Itoa(retryCount))})
-	newHeaders = append(newHeaders, kafka.Header{Key: HeaderOriginalTopic, Value: []byte(baseTopic)})
-	newHeaders = append(newHeaders, kafka.Header{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(original.Partition))})
-	newHeaders = append(newHeaders, kafka.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(original.Offset, 10))})
+	// 原始位置只在首次失败时记录，之后在整条重试链路上保持不变；其余头覆盖为本次失败的信息，不会重复追加
+	newHeaders.Set(HeaderRetryCount, strconv.Itoa(retryCount))
+	newHeaders.Set(HeaderOriginalTopic, baseTopic)
+	if newHeaders.Get(HeaderOriginalPartition) == "" {
+		newHeaders.Set(HeaderOriginalPartition, strconv.Itoa(original.Partition))
+		newHeaders.Set(HeaderOriginalOffset, strconv.FormatInt(original.Offset, 10))
+	}
 
 	if err != nil {
-		newHeaders = append(newHeaders, kafka.Header{Key: HeaderExceptionFqcn, Value: []byte(fmt.Sprintf("%T", err))})
-		newHeaders = append(newHeaders, kafka.Header{Key: HeaderExceptionMessage, Value: []byte(err.Error())})
+		newHeaders.Set(HeaderExceptionFqcn, fmt.Sprintf("%T", err))
+		newHeaders.Set(HeaderExceptionMessage, truncateHeader(baseTopic, HeaderExceptionMessage, err.Error(), maxHeaderBytes))
 		// In a real scenario, you'd get a proper stack trace.
-		newHeaders = append(newHeaders, kafka.Header{Key: HeaderExceptionStacktrace, Value: []byte("stacktrace not implemented")})
+		newHeaders.Set(HeaderExceptionStacktrace, truncateHeader(baseTopic, HeaderExceptionStacktrace, "stacktrace not implemented", maxHeaderBytes))
 	}
 
 	return kafka.Message{
 		Key:     original.Key,
 		Value:   original.Value,
-		Headers: newHeaders,
+		Headers: []kafka.Header(newHeaders),
 	}
 }
 
-func (h *FailureHandler) isRetryable(err error) bool {
+func isRetryable(config ResilienceConfig, err error) bool {
 	if err == nil {
 		return false
 	}
 	errMsg := err.Error()
-	_, ok := h.config.retryableExceptions[errMsg]
+	_, ok := config.retryableExceptions[errMsg]
 	return ok
 }
 
+// truncatedSuffix 追加在被截断的 Header 值末尾
+const truncatedSuffix = "...(truncated)"
+
+// truncateHeader 将 value 截断到 maxBytes 字节以内(不会截断 UTF-8 字符)，并记录截断次数
+func truncateHeader(topic, key, value string, maxBytes int) string {
+	if len(value) <= maxBytes {
+		return value
+	}
+	headerTruncationsTotal.WithLabelValues(topic, key).Inc()
+	cut := max(maxBytes-len(truncatedSuffix), 0)
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut] + truncatedSuffix
+}
+
+// headerMap 将 Header 转换为 map，便于输出到日志
+func headerMap(headers []kafka.Header) map[string]string {
+	m := make(map[string]string, len(headers))
+	for _, h := range headers {
+		m[h.Key] = string(h.Value)
+	}
+	return m
+}
+
 func getHeaderValue(headers []kafka.Header, key string) string {
 	for _, h := range headers {
 		if h.Key == key {
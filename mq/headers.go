@@ -0,0 +1,77 @@
+package mq
+
+import "github.com/segmentio/kafka-go"
+
+// 通用业务元数据的 Header 键
+const (
+	// HeaderEventType 事件类型，例如 order.created
+	HeaderEventType = "event-type"
+	// HeaderTenantID 租户 ID
+	HeaderTenantID = "tenant-id"
+	// HeaderSchemaVersion 消息体的 schema 版本
+	HeaderSchemaVersion = "schema-version"
+	// HeaderContentType 消息体的内容类型，例如 application/json，消费端据此选择反序列化方式
+	HeaderContentType = "content-type"
+)
+
+// SetHeader 设置消息的 Header，已存在的同名 Header 会被覆盖
+func SetHeader(msg *kafka.Message, key, value string) {
+	carrier := KafkaHeaderCarrier(msg.Headers)
+	carrier.Set(key, value)
+	msg.Headers = carrier
+}
+
+// GetHeader 读取 Header 的值，第二个返回值表示该 Header 是否存在
+func GetHeader(headers []kafka.Header, key string) (string, bool) {
+	for _, h := range headers {
+		if h.Key == key {
+			return string(h.Value), true
+		}
+	}
+	return "", false
+}
+
+// HeaderBuilder 用于以统一的方式构建业务元数据 Header:
+//
+//	headers := mq.NewHeaderBuilder().EventType("order.created").TenantID(tenant).SchemaVersion("v2").Build()
+type HeaderBuilder struct {
+	carrier KafkaHeaderCarrier
+}
+
+// NewHeaderBuilder 创建一个空的 HeaderBuilder
+func NewHeaderBuilder() *HeaderBuilder {
+	return &HeaderBuilder{}
+}
+
+// Set 设置任意 Header
+func (b *HeaderBuilder) Set(key, value string) *HeaderBuilder {
+	b.carrier.Set(key, value)
+	return b
+}
+
+// EventType 设置 event-type
+func (b *HeaderBuilder) EventType(eventType string) *HeaderBuilder {
+	return b.Set(HeaderEventType, eventType)
+}
+
+// TenantID 设置 tenant-id
+func (b *HeaderBuilder) TenantID(tenantID string) *HeaderBuilder {
+	return b.Set(HeaderTenantID, tenantID)
+}
+
+// SchemaVersion 设置 schema-version
+func (b *HeaderBuilder) SchemaVersion(version string) *HeaderBuilder {
+	return b.Set(HeaderSchemaVersion, version)
+}
+
+// Build 返回构建好的 Header 列表
+func (b *HeaderBuilder) Build() []kafka.Header {
+	return append([]kafka.Header(nil), b.carrier...)
+}
+
+// ApplyTo 将构建好的 Header 合并到消息中，同名 Header 会被覆盖
+func (b *HeaderBuilder) ApplyTo(msg *kafka.Message) {
+	for _, h := range b.carrier {
+		SetHeader(msg, h.Key, string(h.Value))
+	}
+}
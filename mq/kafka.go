@@ -3,13 +3,53 @@ package mq
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/wangyingjie930/nexus-pkg/logger"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 	"go.opentelemetry.io/otel"
 )
 
+// HeaderMessageID 携带消息的全局唯一 ID，消费端可以据此去重
+const HeaderMessageID = "message-id"
+
+// ErrAsyncWriter 表示需要同步确认的调用收到了一个异步模式的 Writer
+var ErrAsyncWriter = errors.New("mq: writer is in async mode, delivery errors cannot be observed")
+
+// ErrNoBrokers 表示没有配置任何 Kafka broker
+var ErrNoBrokers = errors.New("mq: no kafka brokers configured")
+
+// ValidateBrokers 校验 broker 列表: 不能为空，每一项都必须是 host:port 形式
+func ValidateBrokers(brokers []string) error {
+	if len(brokers) == 0 {
+		return ErrNoBrokers
+	}
+	for _, broker := range brokers {
+		if strings.TrimSpace(broker) == "" {
+			return fmt.Errorf("%w: empty broker address in %v", ErrNoBrokers, brokers)
+		}
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			return fmt.Errorf("invalid kafka broker address %q: %w", broker, err)
+		}
+	}
+	return nil
+}
+
+// MessageWriter 是发送 Kafka 消息的最小接口，*kafka.Writer 与 NoopWriter 都实现了它。
+// 依赖该接口而不是 *kafka.Writer 的组件可以在测试中注入记录消息或模拟失败的实现。
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+var (
+	_ MessageWriter = (*kafka.Writer)(nil)
+	_ MessageWriter = (*NoopWriter)(nil)
+)
+
 // KafkaHeaderCarrier 实现了 opentelemetry.TextMapCarrier 接口
 // 它允许我们将追踪上下文注入和提取到 Kafka 消息的 Header 中
 type KafkaHeaderCarrier []kafka.Header
@@ -46,33 +86,134 @@ func (c KafkaHeaderCarrier) Keys() []string {
 	return keys
 }
 
-// NewKafkaWriter 创建一个新的 Kafka 生产者
-func NewKafkaWriter(brokers []string, topic string) *kafka.Writer {
-	return &kafka.Writer{
+// BalancerStrategy 决定 Writer 如何为消息选择分区
+type BalancerStrategy string
+
+const (
+	// BalancerHash 按消息 key 的 FNV-1a 哈希选择分区，相同 key 的消息总是写入同一分区以保证顺序；
+	// key 为空的消息轮询写入。这是默认策略。
+	BalancerHash BalancerStrategy = "hash"
+	// BalancerMurmur2 与 BalancerHash 相同，但使用与 Java 客户端一致的 murmur2 哈希，
+	// 需要与 Java 生产者写入同一主题并保持分区一致时使用
+	BalancerMurmur2 BalancerStrategy = "murmur2"
+	// BalancerLeastBytes 选择已写入字节数最少的分区，忽略 key，只适用于不要求顺序的主题
+	BalancerLeastBytes BalancerStrategy = "least-bytes"
+	// BalancerRoundRobin 轮询写入各分区，忽略 key
+	BalancerRoundRobin BalancerStrategy = "round-robin"
+)
+
+// balancer 返回策略对应的 kafka.Balancer，未知策略返回 nil
+func (s BalancerStrategy) balancer() kafka.Balancer {
+	switch s {
+	case BalancerHash:
+		return &kafka.Hash{}
+	case BalancerMurmur2:
+		return kafka.Murmur2Balancer{}
+	case BalancerLeastBytes:
+		return &kafka.LeastBytes{}
+	case BalancerRoundRobin:
+		return &kafka.RoundRobin{}
+	default:
+		return nil
+	}
+}
+
+// WriterOption 用于定制 NewKafkaWriter 等函数创建的 Writer
+type WriterOption func(*kafka.Writer)
+
+// WithBalancer 指定分区选择策略，未知的策略会被忽略并保持默认的 BalancerHash
+func WithBalancer(strategy BalancerStrategy) WriterOption {
+	return func(w *kafka.Writer) {
+		b := strategy.balancer()
+		if b == nil {
+			logger.Logger.Warn().Str("balancer", string(strategy)).Msg("⚠️ Unknown kafka balancer strategy, keeping the default")
+			return
+		}
+		w.Balancer = b
+	}
+}
+
+// applyWriterOptions 依次应用 opts
+func applyWriterOptions(w *kafka.Writer, opts []WriterOption) *kafka.Writer {
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// NewKafkaWriter 创建一个新的 Kafka 生产者。
+// 默认使用 BalancerHash，相同 key 的消息写入同一分区，可以通过 WithBalancer 修改。
+func NewKafkaWriter(brokers []string, topic string, opts ...WriterOption) *kafka.Writer {
+	return applyWriterOptions(&kafka.Writer{
 		Addr:     kafka.TCP(brokers...),
 		Topic:    topic,
-		Balancer: &kafka.LeastBytes{},
+		Balancer: &kafka.Hash{},
 		// 关键改动：开启异步模式
 		Async: true,
 		// 可以配合异步模式调整批量参数，以提升吞吐量
 		BatchSize:    100,
 		BatchTimeout: 10 * time.Millisecond,
+	}, opts)
+}
+
+// NewValidatedKafkaWriter 与 NewKafkaWriter 相同，但会先校验 broker 列表，
+// 避免错误的配置在第一次发送时才在 kafka-go 内部失败
+func NewValidatedKafkaWriter(brokers []string, topic string, opts ...WriterOption) (*kafka.Writer, error) {
+	if err := ValidateBrokers(brokers); err != nil {
+		return nil, err
+	}
+	return NewKafkaWriter(brokers, topic, opts...), nil
+}
+
+// NewKafkaSyncWriter 创建一个同步模式的 Kafka 生产者，WriteMessages 会等待所有 ISR 副本确认后才返回。
+// 适用于必须确认消息已落盘才能继续的关键消息，配合 ProduceMessageSync 使用。
+// 与 NewKafkaWriter 一样默认使用 BalancerHash。
+func NewKafkaSyncWriter(brokers []string, topic string, opts ...WriterOption) *kafka.Writer {
+	return applyWriterOptions(&kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+	}, opts)
+}
+
+// NewValidatedKafkaSyncWriter 与 NewKafkaSyncWriter 相同，但会先校验 broker 列表
+func NewValidatedKafkaSyncWriter(brokers []string, topic string, opts ...WriterOption) (*kafka.Writer, error) {
+	if err := ValidateBrokers(brokers); err != nil {
+		return nil, err
 	}
+	return NewKafkaSyncWriter(brokers, topic, opts...), nil
 }
 
 // NewKafkaReader 创建一个新的 Kafka 消费者
 func NewKafkaReader(brokers []string, topic, groupID string) *kafka.Reader {
-	return kafka.NewReader(kafka.ReaderConfig{
+	return kafka.NewReader(readerConfig(brokers, topic, groupID))
+}
+
+// NewValidatedKafkaReader 与 NewKafkaReader 相同，但会先校验 broker 列表，
+// kafka-go 在 broker 列表为空时会直接 panic
+func NewValidatedKafkaReader(brokers []string, topic, groupID string) (*kafka.Reader, error) {
+	if err := ValidateBrokers(brokers); err != nil {
+		return nil, err
+	}
+	return NewKafkaReader(brokers, topic, groupID), nil
+}
+
+// readerConfig 返回消费者的默认配置
+func readerConfig(brokers []string, topic, groupID string) kafka.ReaderConfig {
+	return kafka.ReaderConfig{
 		Brokers:        brokers,
 		GroupID:        groupID,
 		Topic:          topic,
 		MinBytes:       10e3, // 10KB
 		MaxBytes:       10e6, // 10MB
 		CommitInterval: time.Second,
-	})
+	}
 }
 
-// InjectTraceContext 将当前的 OpenTelemetry 追踪上下文注入到 Kafka 消息的 Headers 中
+// InjectTraceContext 将当前的 OpenTelemetry 追踪上下文注入到 Kafka 消息的 Headers 中。
+// 全局 Propagator 同时包含 TraceContext 与 Baggage，tenant_id 等 baggage 也会随消息传递。
 func InjectTraceContext(ctx context.Context, headers *[]kafka.Header) {
 	propagator := otel.GetTextMapPropagator()
 	carrier := KafkaHeaderCarrier(*headers)
@@ -80,19 +221,26 @@ func InjectTraceContext(ctx context.Context, headers *[]kafka.Header) {
 	*headers = carrier
 }
 
-// ExtractTraceContext 从 Kafka 消息的 Headers 中提取 OpenTelemetry 追踪上下文
+// ExtractTraceContext 从 Kafka 消息的 Headers 中提取 OpenTelemetry 追踪上下文与 baggage
 func ExtractTraceContext(ctx context.Context, headers []kafka.Header) context.Context {
 	propagator := otel.GetTextMapPropagator()
 	carrier := KafkaHeaderCarrier(headers)
 	return propagator.Extract(ctx, &carrier)
 }
 
-// ProduceMessage 向 Kafka 发送一条消息，并注入追踪上下文
-func ProduceMessage(ctx context.Context, writer *kafka.Writer, key, value []byte) error {
+// ProduceMessage 向 Kafka 发送一条消息，并注入追踪上下文。
+// 注意: NewKafkaWriter 创建的是异步 Writer，这里返回 nil 并不代表消息已经送达，
+// 投递结果只能通过 Writer.Completion 回调观察；ctx 的截止时间同样不会生效，消息会被缓冲后在后台发送。
+// 需要确认送达或在请求路径上遵守调用方 deadline 时请使用 ProduceMessageSync。
+// topic 通过 RegisterSchema 注册了 schema 时，消息体会在发送前被校验，不符合时返回 ErrSchemaMismatch。
+func ProduceMessage(ctx context.Context, writer *kafka.Writer, key, value []byte, opts ...ProduceOption) error {
 	msg := kafka.Message{
 		Key:   key,
 		Value: value,
 	}
+	if err := applySchema(&msg, writer.Topic, opts); err != nil {
+		return err
+	}
 
 	// 从当前上下文中注入追踪信息到消息头
 	InjectTraceContext(ctx, &msg.Headers)
@@ -101,3 +249,37 @@ func ProduceMessage(ctx context.Context, writer *kafka.Writer, key, value []byte
 
 	return writer.WriteMessages(ctx, msg)
 }
+
+// ProduceMessageSync 向 Kafka 发送一条消息并等待 broker 确认，返回真实的投递错误。
+// writer 必须是同步模式(例如 NewKafkaSyncWriter 创建的 Writer)，否则返回 ErrAsyncWriter。
+//
+// 调用方的 deadline 会被遵守: ctx 已经结束时不会发送消息；在确认之前 ctx 到期时立即返回，
+// 返回的错误满足 errors.Is(err, context.DeadlineExceeded)(或 context.Canceled)。
+// 注意此时消息可能已经在途并最终写入成功，调用方重试时应依赖消息 ID 去重。
+func ProduceMessageSync(ctx context.Context, writer *kafka.Writer, key, value []byte, opts ...ProduceOption) error {
+	if writer.Async {
+		return ErrAsyncWriter
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("produce to topic '%s' not attempted: %w", writer.Topic, err)
+	}
+	msg := kafka.Message{
+		Key:   key,
+		Value: value,
+	}
+	if err := applySchema(&msg, writer.Topic, opts); err != nil {
+		return err
+	}
+	InjectTraceContext(ctx, &msg.Headers)
+
+	if err := writer.WriteMessages(ctx, msg); err != nil {
+		logger.Ctx(ctx).Error().Err(err).Str("topic", writer.Topic).Msg("❌ Failed to produce message to Kafka")
+		// kafka-go 在 ctx 到期时可能返回网络层错误，统一包装出 ctx 的错误以便调用方判断超时
+		if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+			return fmt.Errorf("produce to topic '%s': %w: %w", writer.Topic, ctxErr, err)
+		}
+		return err
+	}
+	logger.Ctx(ctx).Debug().Str("topic", writer.Topic).Msg("message acknowledged by Kafka")
+	return nil
+}
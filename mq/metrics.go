@@ -0,0 +1,47 @@
+package mq
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	retriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "failure_handler",
+		Name:      "retries_total",
+		Help:      "Number of failed messages routed to a retry topic, labeled by original topic.",
+	}, []string{"topic"})
+
+	dltTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "failure_handler",
+		Name:      "dlt_total",
+		Help:      "Number of failed messages routed to a dead-letter topic, labeled by original topic.",
+	}, []string{"topic"})
+
+	headerTruncationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "failure_handler",
+		Name:      "header_truncations_total",
+		Help:      "Number of exception headers truncated to the configured maximum size, labeled by original topic and header.",
+	}, []string{"topic", "header"})
+
+	failurePublishErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "failure_handler",
+		Name:      "publish_errors_total",
+		Help:      "Number of failed messages that could not be written to a retry or dead-letter topic, labeled by original topic.",
+	}, []string{"topic"})
+)
+
+// RegisterMetrics 将 mq 包的指标注册到调用方的 Registerer 中。
+// 重复注册是安全的，已注册的指标会被忽略。
+func RegisterMetrics(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{retriesTotal, dltTotal, headerTruncationsTotal, failurePublishErrorsTotal} {
+		if err := reg.Register(c); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if !errors.As(err, &are) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package mq
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+// NoopWriter 是一个不连接 Kafka 的 Writer，WriteMessages 只记录日志并返回 nil。
+// 它实现了 MessageWriter，用于本地开发等没有 Kafka 的环境:
+// broker 列表未通过 ValidateBrokers 校验时可以用它替代真实的 Writer。
+type NoopWriter struct {
+	Topic string
+}
+
+// NewNoopWriter 创建一个 NoopWriter，topic 仅用于日志
+func NewNoopWriter(topic string) *NoopWriter {
+	return &NoopWriter{Topic: topic}
+}
+
+// WriteMessages 丢弃消息并记录日志
+func (w *NoopWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, msg := range msgs {
+		topic := msg.Topic
+		if topic == "" {
+			topic = w.Topic
+		}
+		logger.Ctx(ctx).Info().
+			Str("topic", topic).
+			Bytes("key", msg.Key).
+			Int("size", len(msg.Value)).
+			Msg("ℹ️ Kafka is disabled, message dropped by no-op writer")
+	}
+	return nil
+}
+
+// Close 什么也不做
+func (w *NoopWriter) Close() error {
+	return nil
+}
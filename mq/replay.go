@@ -0,0 +1,182 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+// ReplayOptions 控制死信重放的行为
+type ReplayOptions struct {
+	// DryRun 为 true 时只统计会被重放的消息数量，不实际投递
+	DryRun bool
+	// Security 为读取死信主题和重新投递时使用的 SASL/TLS 配置，零值表示明文且无认证
+	Security SecurityConfig
+	// IdleTimeout 为单次读取的最长等待时间，<= 0 时使用默认的 10 秒。
+	// 分区末尾的 offset 可能是事务控制记录，或已被压缩/过期删除，永远读不到；
+	// 在快照范围内等待超过该时长仍没有消息时，认为该分区已经重放完毕。
+	IdleTimeout time.Duration
+}
+
+// defaultReplayIdleTimeout 是 ReplayOptions.IdleTimeout 的默认值
+const defaultReplayIdleTimeout = 10 * time.Second
+
+// partitionReader 是 replayPartition 使用的 *kafka.Reader 方法子集，便于在测试中替换
+type partitionReader interface {
+	ReadMessage(ctx context.Context) (kafka.Message, error)
+	// Offset 返回下一条待读取消息的 offset
+	Offset() int64
+}
+
+// Replayer 将死信主题(由 FailureHandler 写入)中的消息重新投递回原始主题，
+// 用于修复消费逻辑的缺陷之后补偿处理失败的消息。
+//
+// 每次 Replay 读取调用时刻各分区已有的全部消息，不记录重放进度；
+// 重复调用会再次投递同样的消息，应通过 filter 限定范围，或依赖消费端基于 message-id 的幂等处理。
+type Replayer struct {
+	brokers []string
+	writer  *kafka.Writer
+	opts    ReplayOptions
+}
+
+// NewReplayer 创建一个死信重放器
+func NewReplayer(brokers []string, opts ReplayOptions) *Replayer {
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = defaultReplayIdleTimeout
+	}
+	return &Replayer{
+		brokers: brokers,
+		// 重放需要确认写入成功，因此使用同步写入；主题由每条消息指定
+		writer: NewSecureKafkaSyncWriter(brokers, "", opts.Security),
+		opts:   opts,
+	}
+}
+
+// Replay 读取 dltTopic 中的消息，将 filter 返回 true(filter 为 nil 时视为全部)的消息
+// 去掉死信与重试相关的 Header 后投递回 dlt-original-topic 指定的原始主题，返回重放(或 DryRun 时将被重放)的消息数量。
+func (r *Replayer) Replay(ctx context.Context, dltTopic string, filter func(kafka.Message) bool) (int, error) {
+	client := newAdminClient(r.brokers, r.opts.Security)
+	partitions, err := topicPartitions(ctx, client, dltTopic)
+	if err != nil {
+		return 0, err
+	}
+	bounds, err := listOffsets(ctx, client, dltTopic, partitions, kafka.FirstOffsetOf)
+	if err != nil {
+		return 0, err
+	}
+	latest, err := latestOffsets(ctx, client, dltTopic, partitions)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, b := range bounds {
+		n, err := r.replayPartition(ctx, dltTopic, b.Partition, b.FirstOffset, latest[b.Partition], filter)
+		replayed += n
+		if err != nil {
+			return replayed, err
+		}
+	}
+
+	logger.Logger.Info().Str("dltTopic", dltTopic).Int("replayed", replayed).Bool("dryRun", r.opts.DryRun).Msg("✅ DLT replay finished")
+	return replayed, nil
+}
+
+// replayPartition 重放一个分区中 [first, last) 范围内的消息
+func (r *Replayer) replayPartition(ctx context.Context, topic string, partition int, first, last int64, filter func(kafka.Message) bool) (int, error) {
+	if first >= last {
+		return 0, nil
+	}
+	reader := r.newPartitionReader(topic, partition)
+	defer reader.Close()
+	if err := reader.SetOffset(first); err != nil {
+		return 0, fmt.Errorf("failed to seek %s[%d] to %d: %w", topic, partition, first, err)
+	}
+	return r.replayUntil(ctx, reader, topic, partition, last, filter)
+}
+
+// replayUntil 从 reader 的当前位置读取并重放消息，直到读到 last(调用时的高水位)为止。
+// 末尾的 offset 不一定对应一条可读的消息，因此以 reader 的位置和单次读取超时判断是否结束，而不是等待某个确切的 offset。
+func (r *Replayer) replayUntil(ctx context.Context, reader partitionReader, topic string, partition int, last int64, filter func(kafka.Message) bool) (int, error) {
+	replayed := 0
+	for reader.Offset() < last {
+		readCtx, cancel := context.WithTimeout(ctx, r.opts.IdleTimeout)
+		msg, err := reader.ReadMessage(readCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				logger.Ctx(ctx).Debug().Str("topic", topic).Int("partition", partition).
+					Int64("offset", reader.Offset()).Int64("highWatermark", last).
+					Msg("no readable messages left before the high watermark, partition replay finished")
+				return replayed, nil
+			}
+			return replayed, fmt.Errorf("failed to read %s[%d]: %w", topic, partition, err)
+		}
+		// 跳过的 offset 之后可能直接读到 Replay 开始后才写入的消息，这些消息不在本次重放范围内
+		if msg.Offset >= last {
+			return replayed, nil
+		}
+		if filter == nil || filter(msg) {
+			if err := r.republish(ctx, msg); err != nil {
+				return replayed, err
+			}
+			replayed++
+		}
+	}
+	return replayed, nil
+}
+
+// newPartitionReader 创建直接读取单个分区(不加入消费组)的 reader
+func (r *Replayer) newPartitionReader(topic string, partition int) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   r.brokers,
+		Topic:     topic,
+		Partition: partition,
+		MaxBytes:  10e6, // 10MB
+		Dialer:    r.opts.Security.Dialer(),
+	})
+}
+
+// republish 将死信消息还原后投递回原始主题
+func (r *Replayer) republish(ctx context.Context, msg kafka.Message) error {
+	originalTopic := getHeaderValue(msg.Headers, HeaderOriginalTopic)
+	if originalTopic == "" {
+		return fmt.Errorf("dlt message %s[%d]@%d has no %s header", msg.Topic, msg.Partition, msg.Offset, HeaderOriginalTopic)
+	}
+	if r.opts.DryRun {
+		return nil
+	}
+
+	out := kafka.Message{
+		Topic:   originalTopic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: stripFailureHeaders(msg.Headers),
+	}
+	if err := r.writer.WriteMessages(ctx, out); err != nil {
+		return fmt.Errorf("failed to replay dlt message to '%s': %w", originalTopic, err)
+	}
+	return nil
+}
+
+// stripFailureHeaders 去掉 FailureHandler 添加的死信与重试 Header，使消息以全新的状态重新进入处理流程
+func stripFailureHeaders(headers []kafka.Header) []kafka.Header {
+	out := make([]kafka.Header, 0, len(headers))
+	for _, h := range headers {
+		if strings.HasPrefix(h.Key, "dlt-") || h.Key == HeaderRetryCount || h.Key == HeaderRetryDelay {
+			continue
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// Close 关闭底层的 writer
+func (r *Replayer) Close() error {
+	return r.writer.Close()
+}
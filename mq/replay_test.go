@@ -0,0 +1,110 @@
+package mq
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakePartitionReader 按顺序返回预置的消息，并像 *kafka.Reader 一样把位置推进到最后一条消息之后；
+// 消息耗尽后阻塞到 ctx 结束
+type fakePartitionReader struct {
+	offset int64
+	msgs   []kafka.Message
+}
+
+func (r *fakePartitionReader) ReadMessage(ctx context.Context) (kafka.Message, error) {
+	if len(r.msgs) == 0 {
+		<-ctx.Done()
+		return kafka.Message{}, ctx.Err()
+	}
+	msg := r.msgs[0]
+	r.msgs = r.msgs[1:]
+	r.offset = msg.Offset + 1
+	return msg, nil
+}
+
+func (r *fakePartitionReader) Offset() int64 { return r.offset }
+
+func dltMessages(offsets ...int64) []kafka.Message {
+	msgs := make([]kafka.Message, len(offsets))
+	for i, o := range offsets {
+		msgs[i] = kafka.Message{
+			Topic:   "orders.dlt",
+			Offset:  o,
+			Headers: []kafka.Header{{Key: HeaderOriginalTopic, Value: []byte("orders")}},
+		}
+	}
+	return msgs
+}
+
+func newDryRunReplayer(t *testing.T, idle time.Duration) *Replayer {
+	t.Helper()
+	r := NewReplayer([]string{"localhost:9092"}, ReplayOptions{DryRun: true, IdleTimeout: idle})
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+func TestReplayStopsAtHighWatermark(t *testing.T) {
+	r := newDryRunReplayer(t, 5*time.Second)
+	reader := &fakePartitionReader{msgs: dltMessages(0, 1, 2)}
+
+	start := time.Now()
+	n, err := r.replayUntil(context.Background(), reader, "orders.dlt", 0, 3, nil)
+	if err != nil {
+		t.Fatalf("replayUntil returned error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("replayed %d messages, want 3", n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("replayUntil took %v, want it to stop as soon as the high watermark is reached", elapsed)
+	}
+}
+
+func TestReplayFinishesWhenTailOffsetIsUnreadable(t *testing.T) {
+	r := newDryRunReplayer(t, 50*time.Millisecond)
+	// offset 3 是事务控制记录(或已被压缩删除)，高水位为 4，永远读不到 offset 3 的消息
+	reader := &fakePartitionReader{msgs: dltMessages(0, 1, 2)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	n, err := r.replayUntil(ctx, reader, "orders.dlt", 0, 4, nil)
+	if err != nil {
+		t.Fatalf("replayUntil returned error: %v, want the gap at the tail to end the replay", err)
+	}
+	if n != 3 {
+		t.Errorf("replayed %d messages, want 3", n)
+	}
+}
+
+func TestReplaySkipsMessagesWrittenAfterSnapshot(t *testing.T) {
+	r := newDryRunReplayer(t, 50*time.Millisecond)
+	// offset 2 被压缩删除，offset 4 是 Replay 开始之后才写入的消息
+	reader := &fakePartitionReader{msgs: dltMessages(0, 1, 4)}
+
+	n, err := r.replayUntil(context.Background(), reader, "orders.dlt", 0, 3, nil)
+	if err != nil {
+		t.Fatalf("replayUntil returned error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("replayed %d messages, want 2", n)
+	}
+}
+
+func TestReplayReturnsErrorWhenCallerCancels(t *testing.T) {
+	r := newDryRunReplayer(t, time.Minute)
+	reader := &fakePartitionReader{msgs: dltMessages(0)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	n, err := r.replayUntil(ctx, reader, "orders.dlt", 0, 5, nil)
+	if err == nil {
+		t.Fatal("replayUntil returned nil, want the caller's deadline to be reported")
+	}
+	if n != 1 {
+		t.Errorf("replayed %d messages before the deadline, want 1", n)
+	}
+}
@@ -0,0 +1,122 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+// RetryConsumer 与 FailureHandler 配合，完成延迟重试:
+// 它消费一个重试主题(例如 orders.retry.5s)，根据消息的写入时间和延迟秒数计算到期时间，
+// 等到期后再把消息重新投递回原始主题，由业务消费者再次处理。
+//
+// 同一分区内的消息按写入时间有序，而同一重试主题的延迟相同，因此阻塞等待队首消息到期不会推迟后续消息。
+type RetryConsumer struct {
+	reader retryReader
+	writer retryWriter
+	// delay 在消息缺少 retry-delay-sec 头时使用
+	delay time.Duration
+}
+
+// retryReader 和 retryWriter 是 RetryConsumer 使用的 kafka-go 方法子集，便于在测试中替换
+type retryReader interface {
+	messageFetcher
+	Close() error
+}
+
+type retryWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// NewRetryConsumer 创建一个重试主题的消费者，delay 为该重试主题对应的延迟
+func NewRetryConsumer(brokers []string, retryTopic, groupID string, delay time.Duration) *RetryConsumer {
+	return NewSecureRetryConsumer(brokers, retryTopic, groupID, delay, SecurityConfig{})
+}
+
+// NewSecureRetryConsumer 与 NewRetryConsumer 相同，但消费重试主题和重新投递都使用 sec 中的 SASL/TLS 配置
+func NewSecureRetryConsumer(brokers []string, retryTopic, groupID string, delay time.Duration, sec SecurityConfig) *RetryConsumer {
+	// 重新投递需要确认写入成功后才能提交 offset，因此使用同步写入；主题由每条消息指定
+	return &RetryConsumer{
+		reader: NewSecureKafkaReader(brokers, retryTopic, groupID, sec),
+		writer: NewSecureKafkaSyncWriter(brokers, "", sec),
+		delay:  delay,
+	}
+}
+
+// Run 持续消费重试主题，直到 ctx 被取消
+func (c *RetryConsumer) Run(ctx context.Context) error {
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch retry message: %w", err)
+		}
+
+		// 等待消息到期
+		if wait := time.Until(c.dueTime(msg)); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			}
+		}
+
+		if err := c.republish(ctx, msg); err != nil {
+			return err
+		}
+		if err := c.reader.CommitMessages(ctx, msg); err != nil {
+			return fmt.Errorf("failed to commit retry message: %w", err)
+		}
+	}
+}
+
+// Close 关闭底层的 reader 和 writer
+func (c *RetryConsumer) Close() error {
+	rErr := c.reader.Close()
+	wErr := c.writer.Close()
+	if rErr != nil {
+		return rErr
+	}
+	return wErr
+}
+
+// dueTime 计算消息的到期时间: 写入时间 + 延迟
+func (c *RetryConsumer) dueTime(msg kafka.Message) time.Time {
+	delay := c.delay
+	if sec, err := strconv.Atoi(getHeaderValue(msg.Headers, HeaderRetryDelay)); err == nil {
+		delay = time.Duration(sec) * time.Second
+	}
+	return msg.Time.Add(delay)
+}
+
+// republish 将消息投递回原始主题
+func (c *RetryConsumer) republish(ctx context.Context, msg kafka.Message) error {
+	originalTopic := getHeaderValue(msg.Headers, HeaderOriginalTopic)
+	if originalTopic == "" {
+		return fmt.Errorf("retry message %s[%d]@%d has no %s header", msg.Topic, msg.Partition, msg.Offset, HeaderOriginalTopic)
+	}
+
+	out := kafka.Message{
+		Topic:   originalTopic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: msg.Headers,
+	}
+	if err := c.writer.WriteMessages(ctx, out); err != nil {
+		return fmt.Errorf("failed to republish retry message to '%s': %w", originalTopic, err)
+	}
+	logger.Ctx(ExtractTraceContext(ctx, msg.Headers)).Info().
+		Str("retryTopic", msg.Topic).
+		Str("originalTopic", originalTopic).
+		Msg("retry message republished")
+	return nil
+}
@@ -0,0 +1,110 @@
+package mq
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeRetryReader 在 fakeFetcher 的基础上补充 Close
+type fakeRetryReader struct {
+	fakeFetcher
+}
+
+func (r *fakeRetryReader) Close() error { return nil }
+
+// recordingWriter 记录所有写入的消息
+type recordingWriter struct {
+	mu      sync.Mutex
+	written []kafka.Message
+}
+
+func (w *recordingWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+func (w *recordingWriter) Close() error { return nil }
+
+func (w *recordingWriter) messages() []kafka.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]kafka.Message(nil), w.written...)
+}
+
+func retryMessage(written time.Time, delaySec string) kafka.Message {
+	return kafka.Message{
+		Topic: "orders.retry.5s",
+		Time:  written,
+		Value: []byte(`{"orderId":"42"}`),
+		Headers: []kafka.Header{
+			{Key: HeaderOriginalTopic, Value: []byte("orders")},
+			{Key: HeaderRetryDelay, Value: []byte(delaySec)},
+		},
+	}
+}
+
+func TestRetryConsumerDueTime(t *testing.T) {
+	written := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := &RetryConsumer{delay: 30 * time.Second}
+
+	if got, want := c.dueTime(retryMessage(written, "5")), written.Add(5*time.Second); !got.Equal(want) {
+		t.Errorf("dueTime with header = %v, want %v", got, want)
+	}
+
+	noHeader := kafka.Message{Time: written}
+	if got, want := c.dueTime(noHeader), written.Add(30*time.Second); !got.Equal(want) {
+		t.Errorf("dueTime without header = %v, want %v", got, want)
+	}
+
+	if got, want := c.dueTime(retryMessage(written, "not-a-number")), written.Add(30*time.Second); !got.Equal(want) {
+		t.Errorf("dueTime with malformed header = %v, want %v", got, want)
+	}
+}
+
+func TestRetryConsumerDoesNotRepublishBeforeDue(t *testing.T) {
+	reader := &fakeRetryReader{fakeFetcher{pending: []kafka.Message{retryMessage(time.Now(), "5")}}}
+	writer := &recordingWriter{}
+	c := &RetryConsumer{reader: reader, writer: writer, delay: 5 * time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	if got := writer.messages(); len(got) != 0 {
+		t.Errorf("republished %d messages before they were due, want 0", len(got))
+	}
+	if got := reader.committedOffsets(); len(got) != 0 {
+		t.Errorf("committed offsets = %v before the message was due, want none", got)
+	}
+}
+
+func TestRetryConsumerRepublishesDueMessage(t *testing.T) {
+	reader := &fakeRetryReader{fakeFetcher{pending: []kafka.Message{retryMessage(time.Now().Add(-10*time.Second), "5")}}}
+	writer := &recordingWriter{}
+	c := &RetryConsumer{reader: reader, writer: writer, delay: 5 * time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	got := writer.messages()
+	if len(got) != 1 {
+		t.Fatalf("republished %d messages, want 1", len(got))
+	}
+	if got[0].Topic != "orders" {
+		t.Errorf("republished to %q, want the original topic %q", got[0].Topic, "orders")
+	}
+	if offsets := reader.committedOffsets(); len(offsets) != 1 {
+		t.Errorf("committed %d messages, want 1", len(offsets))
+	}
+}
@@ -0,0 +1,148 @@
+package mq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrSchemaMismatch 表示消息体不符合 topic 注册的 schema，消息不会被发送
+var ErrSchemaMismatch = errors.New("mq: payload does not match schema")
+
+// SchemaValidator 校验消息体是否符合 schema。
+// NewJSONSchemaValidator 提供基于 JSON Schema 的实现，也可以自行实现(例如 Protobuf、Avro)后通过 RegisterSchema 注册。
+type SchemaValidator interface {
+	Validate(payload []byte) error
+}
+
+// SchemaValidatorFunc 让普通函数实现 SchemaValidator
+type SchemaValidatorFunc func(payload []byte) error
+
+// Validate 实现 SchemaValidator 接口
+func (f SchemaValidatorFunc) Validate(payload []byte) error {
+	return f(payload)
+}
+
+// jsonSchemaValidator 按编译好的 JSON Schema 校验消息体
+type jsonSchemaValidator struct {
+	schema *jsonschema.Schema
+}
+
+// NewJSONSchemaValidator 编译 JSON Schema 文档(支持 draft 4/6/7/2019-09/2020-12，未声明 $schema 时按 2020-12 处理)，
+// 返回按该 schema 校验消息体的 SchemaValidator，类型、嵌套结构、enum、required 等约束都会被检查。
+// schema 本身不合法时返回错误，应在启动阶段调用。
+func NewJSONSchemaValidator(schema []byte) (SchemaValidator, error) {
+	const url = "mem://schema.json"
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(url, bytes.NewReader(schema)); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return jsonSchemaValidator{schema: compiled}, nil
+}
+
+// Validate 实现 SchemaValidator 接口
+func (v jsonSchemaValidator) Validate(payload []byte) error {
+	// 使用 json.Number 保留数字的原始精度，integer 等约束才能准确判断
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("payload is not valid JSON: %w", err)
+	}
+	return v.schema.Validate(doc)
+}
+
+// RequireJSONFields 返回一个轻量的校验器: 消息体必须是 JSON 对象，且包含全部 fields 顶层字段。
+// 它只检查字段是否存在，不校验类型与嵌套结构，需要完整校验时使用 NewJSONSchemaValidator。
+func RequireJSONFields(fields ...string) SchemaValidator {
+	return SchemaValidatorFunc(func(payload []byte) error {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &obj); err != nil {
+			return fmt.Errorf("payload is not a JSON object: %w", err)
+		}
+		for _, field := range fields {
+			if _, ok := obj[field]; !ok {
+				return fmt.Errorf("missing required field '%s'", field)
+			}
+		}
+		return nil
+	})
+}
+
+type topicSchema struct {
+	version   string
+	validator SchemaValidator
+}
+
+var (
+	schemaLock sync.RWMutex
+	schemas    = map[string]topicSchema{}
+)
+
+// RegisterSchema 为 topic 注册 schema 版本与校验器(可以为 nil，表示只写入版本号)。
+// 注册后 ProduceMessage、ProduceMessageSync 以及 outbox 在发送前都会校验消息体，
+// 并写入 schema-version 消息头；未注册的 topic 不做任何校验。重复注册会覆盖之前的设置。
+func RegisterSchema(topic, version string, validator SchemaValidator) {
+	schemaLock.Lock()
+	defer schemaLock.Unlock()
+	schemas[topic] = topicSchema{version: version, validator: validator}
+}
+
+// CheckSchema 按 topic 注册的 schema 校验消息体，返回应写入 schema-version 消息头的版本。
+// topic 未注册时返回空版本和 nil；校验失败时返回包装了 ErrSchemaMismatch 的错误。
+func CheckSchema(topic string, payload []byte) (string, error) {
+	schemaLock.RLock()
+	schema, ok := schemas[topic]
+	schemaLock.RUnlock()
+	if !ok {
+		return "", nil
+	}
+	if schema.validator != nil {
+		if err := schema.validator.Validate(payload); err != nil {
+			return "", fmt.Errorf("%w: topic '%s' schema %s: %w", ErrSchemaMismatch, topic, schema.version, err)
+		}
+	}
+	return schema.version, nil
+}
+
+// ProduceOption 用于定制单次发送
+type ProduceOption func(*produceOptions)
+
+type produceOptions struct {
+	schemaVersion string
+}
+
+// WithSchemaVersion 为消息写入 schema-version 消息头，优先于 RegisterSchema 注册的版本
+func WithSchemaVersion(version string) ProduceOption {
+	return func(o *produceOptions) {
+		o.schemaVersion = version
+	}
+}
+
+// applySchema 校验消息体并写入 schema-version 消息头
+func applySchema(msg *kafka.Message, topic string, opts []ProduceOption) error {
+	var o produceOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	version, err := CheckSchema(topic, msg.Value)
+	if err != nil {
+		return err
+	}
+	if o.schemaVersion != "" {
+		version = o.schemaVersion
+	}
+	if version != "" {
+		SetHeader(msg, HeaderSchemaVersion, version)
+	}
+	return nil
+}
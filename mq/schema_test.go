@@ -0,0 +1,112 @@
+package mq
+
+import (
+	"errors"
+	"testing"
+)
+
+const orderCreatedSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["orderId", "amount", "status", "items"],
+	"properties": {
+		"orderId": {"type": "string", "minLength": 1},
+		"amount": {"type": "number", "minimum": 0},
+		"status": {"enum": ["CREATED", "PAID"]},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"items": {
+				"type": "object",
+				"required": ["sku", "quantity"],
+				"properties": {
+					"sku": {"type": "string"},
+					"quantity": {"type": "integer", "minimum": 1}
+				}
+			}
+		}
+	}
+}`
+
+// resetSchemas 清空全局注册的 schema，避免测试之间互相影响
+func resetSchemas(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		schemaLock.Lock()
+		defer schemaLock.Unlock()
+		schemas = map[string]topicSchema{}
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestJSONSchemaValidator(t *testing.T) {
+	validator, err := NewJSONSchemaValidator([]byte(orderCreatedSchema))
+	if err != nil {
+		t.Fatalf("NewJSONSchemaValidator returned error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{"valid", `{"orderId":"o-1","amount":12.5,"status":"PAID","items":[{"sku":"A","quantity":2}]}`, false},
+		{"wrong type", `{"orderId":"o-1","amount":"12.5","status":"PAID","items":[{"sku":"A","quantity":2}]}`, true},
+		{"enum violation", `{"orderId":"o-1","amount":12.5,"status":"SHIPPED","items":[{"sku":"A","quantity":2}]}`, true},
+		{"nested required field", `{"orderId":"o-1","amount":12.5,"status":"PAID","items":[{"sku":"A"}]}`, true},
+		{"nested type", `{"orderId":"o-1","amount":12.5,"status":"PAID","items":[{"sku":"A","quantity":1.5}]}`, true},
+		{"missing field", `{"orderId":"o-1","status":"PAID","items":[{"sku":"A","quantity":2}]}`, true},
+		{"not JSON", `order o-1`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Validate([]byte(tt.payload))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewJSONSchemaValidatorRejectsInvalidSchema(t *testing.T) {
+	if _, err := NewJSONSchemaValidator([]byte(`{"type": "no-such-type"}`)); err == nil {
+		t.Error("NewJSONSchemaValidator accepted an invalid schema, want an error")
+	}
+	if _, err := NewJSONSchemaValidator([]byte(`{not json`)); err == nil {
+		t.Error("NewJSONSchemaValidator accepted malformed JSON, want an error")
+	}
+}
+
+func TestCheckSchemaWithJSONSchema(t *testing.T) {
+	resetSchemas(t)
+	validator, err := NewJSONSchemaValidator([]byte(orderCreatedSchema))
+	if err != nil {
+		t.Fatalf("NewJSONSchemaValidator returned error: %v", err)
+	}
+	RegisterSchema("orders.created", "v2", validator)
+
+	version, err := CheckSchema("orders.created", []byte(`{"orderId":"o-1","amount":1,"status":"CREATED","items":[{"sku":"A","quantity":1}]}`))
+	if err != nil || version != "v2" {
+		t.Errorf("CheckSchema(valid) = %q, %v; want v2, nil", version, err)
+	}
+
+	_, err = CheckSchema("orders.created", []byte(`{"orderId":"o-1","amount":-1,"status":"CREATED","items":[{"sku":"A","quantity":1}]}`))
+	if !errors.Is(err, ErrSchemaMismatch) {
+		t.Errorf("CheckSchema(invalid) error = %v, want ErrSchemaMismatch", err)
+	}
+
+	if version, err := CheckSchema("payments.settled", []byte(`anything`)); version != "" || err != nil {
+		t.Errorf("CheckSchema(unregistered) = %q, %v; want empty version and nil", version, err)
+	}
+}
+
+func TestRequireJSONFieldsOnlyChecksPresence(t *testing.T) {
+	validator := RequireJSONFields("orderId")
+	if err := validator.Validate([]byte(`{"orderId": 42}`)); err != nil {
+		t.Errorf("Validate() error = %v, want nil: RequireJSONFields does not check types", err)
+	}
+	if err := validator.Validate([]byte(`{"id": "o-1"}`)); err == nil {
+		t.Error("Validate() accepted a payload without orderId, want an error")
+	}
+}
@@ -0,0 +1,90 @@
+package mq
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func testSecurity() SecurityConfig {
+	return SecurityConfig{
+		SASL: plain.Mechanism{Username: "order-service", Password: "secret"},
+		TLS:  &tls.Config{ServerName: "kafka.internal", MinVersion: tls.VersionTLS12},
+	}
+}
+
+// assertTransport 断言 rt 是携带 sec 中 SASL 机制与 TLS 配置的 *kafka.Transport
+func assertTransport(t *testing.T, name string, rt kafka.RoundTripper, sec SecurityConfig) {
+	t.Helper()
+	transport, ok := rt.(*kafka.Transport)
+	if !ok {
+		t.Fatalf("%s: transport = %T, want *kafka.Transport", name, rt)
+	}
+	if transport.SASL != sec.SASL {
+		t.Errorf("%s: SASL mechanism = %v, want %v", name, transport.SASL, sec.SASL)
+	}
+	if transport.TLS != sec.TLS {
+		t.Errorf("%s: TLS config was not applied", name)
+	}
+}
+
+// assertDialer 断言 d 携带 sec 中的 SASL 机制与 TLS 配置
+func assertDialer(t *testing.T, name string, d *kafka.Dialer, sec SecurityConfig) {
+	t.Helper()
+	if d == nil {
+		t.Fatalf("%s: dialer is nil, want a dialer with SASL/TLS", name)
+	}
+	if d.SASLMechanism != sec.SASL {
+		t.Errorf("%s: SASL mechanism = %v, want %v", name, d.SASLMechanism, sec.SASL)
+	}
+	if d.TLS != sec.TLS {
+		t.Errorf("%s: TLS config was not applied", name)
+	}
+}
+
+func TestSecureClientsCarrySecurityConfig(t *testing.T) {
+	sec := testSecurity()
+	brokers := []string{"kafka-1:9093"}
+
+	assertTransport(t, "sync writer", NewSecureKafkaSyncWriter(brokers, "orders", sec).Transport, sec)
+	assertTransport(t, "async writer", NewSecureKafkaWriter(brokers, "orders", sec).Transport, sec)
+	assertTransport(t, "admin client", newAdminClient(brokers, sec).Transport, sec)
+
+	reader := NewSecureKafkaReader(brokers, "orders", "order-group", sec)
+	defer reader.Close()
+	assertDialer(t, "reader", reader.Config().Dialer, sec)
+
+	handler := NewSecureFailureHandler(brokers, ResilienceConfig{Enabled: true}, noop.NewTracerProvider().Tracer("test"), sec)
+	assertTransport(t, "failure handler writer", handler.getWriter("orders.dlt").Transport, sec)
+
+	retry := NewSecureRetryConsumer(brokers, "orders.retry.5s", "order-group", 0, sec)
+	defer retry.Close()
+	assertTransport(t, "retry consumer writer", retry.writer.(*kafka.Writer).Transport, sec)
+	assertDialer(t, "retry consumer reader", retry.reader.(*kafka.Reader).Config().Dialer, sec)
+
+	replayer := NewReplayer(brokers, ReplayOptions{Security: sec})
+	defer replayer.Close()
+	assertTransport(t, "replayer writer", replayer.writer.Transport, sec)
+	partitionReader := replayer.newPartitionReader("orders.dlt", 0)
+	defer partitionReader.Close()
+	assertDialer(t, "replayer reader", partitionReader.Config().Dialer, sec)
+}
+
+func TestZeroSecurityConfigKeepsDefaults(t *testing.T) {
+	brokers := []string{"kafka-1:9092"}
+
+	// 零值不能把 nil 的 *kafka.Transport 赋给接口字段，否则 kafka-go 不会回退到默认 Transport
+	if rt := newAdminClient(brokers, SecurityConfig{}).Transport; rt != nil {
+		t.Errorf("admin client transport = %T, want nil", rt)
+	}
+	if rt := NewSecureKafkaSyncWriter(brokers, "orders", SecurityConfig{}).Transport; rt != nil {
+		t.Errorf("sync writer transport = %T, want nil", rt)
+	}
+	handler := NewFailureHandler(brokers, ResilienceConfig{Enabled: true}, noop.NewTracerProvider().Tracer("test"))
+	if rt := handler.getWriter("orders.dlt").Transport; rt != nil {
+		t.Errorf("failure handler writer transport = %T, want nil", rt)
+	}
+}
@@ -0,0 +1,142 @@
+package nacos
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/model"
+	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+const defaultDiscoveryCacheTTL = 3 * time.Second
+
+// discoveryCache 按 分组@@服务 缓存健康实例列表。
+// 缓存过期后仍先返回旧列表，同时在后台刷新；Nacos 推送实例变更时立即失效。
+type discoveryCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	// 已订阅变更推送的服务，Close 时取消订阅
+	subscriptions map[string]*vo.SubscribeParam
+}
+
+type cacheEntry struct {
+	instances  []model.Instance
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+func newDiscoveryCache(ttl time.Duration) *discoveryCache {
+	return &discoveryCache{
+		ttl:           ttl,
+		entries:       make(map[string]*cacheEntry),
+		subscriptions: make(map[string]*vo.SubscribeParam),
+	}
+}
+
+// cacheKey 与 Nacos 的分组服务名格式保持一致
+func cacheKey(serviceName, group string) string {
+	return group + "@@" + serviceName
+}
+
+// cachedInstances 返回服务的健康实例列表副本，未命中时同步拉取
+func (c *Client) cachedInstances(serviceName, group string) ([]model.Instance, error) {
+	cache := c.cache
+	key := cacheKey(serviceName, group)
+	cache.mu.Lock()
+	entry, ok := cache.entries[key]
+	if ok {
+		instances := append([]model.Instance(nil), entry.instances...)
+		if time.Since(entry.fetchedAt) >= cache.ttl && !entry.refreshing {
+			entry.refreshing = true
+			go c.refreshInstances(serviceName, group)
+		}
+		cache.mu.Unlock()
+		return instances, nil
+	}
+	cache.mu.Unlock()
+
+	instances, err := c.selectInstances(serviceName, group)
+	if err != nil {
+		return nil, err
+	}
+	c.storeInstances(key, instances)
+	c.subscribe(serviceName, group)
+	return append([]model.Instance(nil), instances...), nil
+}
+
+// refreshInstances 在后台刷新缓存，失败时保留旧列表
+func (c *Client) refreshInstances(serviceName, group string) {
+	key := cacheKey(serviceName, group)
+	instances, err := c.selectInstances(serviceName, group)
+	if err != nil {
+		logger.Logger.Warn().Err(err).Str("service", serviceName).Str("group", group).Msg("⚠️ Failed to refresh cached instances, keeping stale list")
+		c.cache.mu.Lock()
+		if entry, ok := c.cache.entries[key]; ok {
+			entry.refreshing = false
+		}
+		c.cache.mu.Unlock()
+		return
+	}
+	c.storeInstances(key, instances)
+}
+
+func (c *Client) storeInstances(key string, instances []model.Instance) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	c.cache.entries[key] = &cacheEntry{instances: instances, fetchedAt: time.Now()}
+}
+
+// subscribe 订阅服务的实例变更，变更时使缓存失效
+func (c *Client) subscribe(serviceName, group string) {
+	key := cacheKey(serviceName, group)
+	c.cache.mu.Lock()
+	if _, ok := c.cache.subscriptions[key]; ok {
+		c.cache.mu.Unlock()
+		return
+	}
+	param := &vo.SubscribeParam{
+		ServiceName: serviceName,
+		GroupName:   group,
+		SubscribeCallback: func(_ []model.Instance, err error) {
+			if err != nil {
+				return
+			}
+			c.cache.mu.Lock()
+			delete(c.cache.entries, key)
+			c.cache.mu.Unlock()
+		},
+	}
+	c.cache.subscriptions[key] = param
+	c.cache.mu.Unlock()
+
+	if err := c.namingClient.Subscribe(param); err != nil {
+		// 订阅失败时缓存仍会按 TTL 刷新
+		logger.Logger.Warn().Err(err).Str("service", serviceName).Str("group", group).Msg("⚠️ Failed to subscribe to service changes")
+		c.cache.mu.Lock()
+		delete(c.cache.subscriptions, key)
+		c.cache.mu.Unlock()
+	}
+}
+
+// pickWeighted 按权重随机选择一个实例，与 Nacos SelectOneHealthyInstance 的行为一致
+func pickWeighted(instances []model.Instance) model.Instance {
+	var total float64
+	for _, ins := range instances {
+		total += ins.Weight
+	}
+	if total <= 0 {
+		return instances[rand.IntN(len(instances))]
+	}
+	r := rand.Float64() * total
+	for _, ins := range instances {
+		r -= ins.Weight
+		if r < 0 {
+			return ins
+		}
+	}
+	return instances[len(instances)-1]
+}
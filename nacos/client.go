@@ -6,8 +6,12 @@ import (
 	"github.com/nacos-group/nacos-sdk-go/v2/clients"
 	"github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
 	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/v2/model"
 	"github.com/nacos-group/nacos-sdk-go/v2/vo"
 	"github.com/wangyingjie930/nexus-pkg/logger"
+	"strconv"
+	"sync"
+	"time"
 )
 
 // Client 封装了 Nacos 命名客户端
@@ -16,11 +20,32 @@ type Client struct {
 
 	namespaceId string // ✨ 新增: 存储命名空间ID
 	groupName   string // ✨ 新增: 存储默认分组名
+
+	// 记录每个已注册实例的注册参数: 注销时 ephemeral 标记必须与注册时保持一致，否则注销会静默失败；
+	// 后台重新注册时也需要原样重放注册参数
+	registrationsLock sync.Mutex
+	registrations     map[string]vo.RegisterInstanceParam
+	// 后台重新注册的间隔，为 0 时不开启
+	reRegisterInterval time.Duration
+	stopCh             chan struct{}
+	stopOnce           sync.Once
+
+	// 主动健康探测，用于 DiscoverHealthyInstance
+	prober *healthProber
+	// 服务发现缓存，为 nil 时每次发现都实时请求 Nacos
+	cache *discoveryCache
+	// 同可用区优先策略，为 nil 时不区分可用区
+	zone *zoneAffinity
+}
+
+// instanceKey 生成已注册实例的索引键
+func instanceKey(group, serviceName, ip string, port int) string {
+	return group + "@@" + serviceName + "|" + ip + ":" + strconv.Itoa(port)
 }
 
 // ✨ 改造 NewNacosClient 函数，使其不再负责创建配置，只负责创建客户端
 // 原来的 NewNacosClient 改名为 NewNacosClientWithConfigs
-func NewNacosClientWithConfigs(serverConfigs []constant.ServerConfig, clientConfig *constant.ClientConfig, groupName string) (*Client, error) {
+func NewNacosClientWithConfigs(serverConfigs []constant.ServerConfig, clientConfig *constant.ClientConfig, groupName string, opts ...ClientOption) (*Client, error) {
 	if groupName == "" {
 		groupName = "DEFAULT_GROUP"
 		logger.Logger.Printf("⚠️ WARNING: NACOS_GROUP is not set. Using '%s'.", groupName)
@@ -38,72 +63,181 @@ func NewNacosClientWithConfigs(serverConfigs []constant.ServerConfig, clientConf
 
 	namespaceId := clientConfig.NamespaceId
 	logger.Logger.Printf("✅ Successfully connected to Nacos. Namespace: '%s', Group: '%s'", namespaceId, groupName)
-	return &Client{
-		namingClient: namingClient,
-		namespaceId:  namespaceId,
-		groupName:    groupName,
-	}, nil
+	c := &Client{
+		namingClient:  namingClient,
+		namespaceId:   namespaceId,
+		groupName:     groupName,
+		registrations: make(map[string]vo.RegisterInstanceParam),
+		stopCh:        make(chan struct{}),
+		prober:        newHealthProber(defaultProbeTimeout, defaultProbeCacheTTL),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.reRegisterInterval > 0 {
+		go c.reRegisterLoop()
+	}
+	return c, nil
 }
 
-// RegisterServiceInstance 注册一个服务实例到 Nacos
-func (c *Client) RegisterServiceInstance(serviceName, ip string, port int) error {
-	success, err := c.namingClient.RegisterInstance(vo.RegisterInstanceParam{
+// RegisterServiceInstance 注册一个服务实例到 Nacos。
+// 默认注册为临时实例，可以通过 WithEphemeral(false) 注册持久实例；
+// 默认注册到客户端的分组，可以通过 WithGroup 注册到其他分组。
+func (c *Client) RegisterServiceInstance(serviceName, ip string, port int, opts ...RegisterOption) error {
+	o := defaultRegisterOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	group := c.resolveGroup(o.group)
+	if c.zone != nil {
+		if _, ok := o.metadata[c.zone.metadataKey]; !ok {
+			metadata := make(map[string]string, len(o.metadata)+1)
+			for k, v := range o.metadata {
+				metadata[k] = v
+			}
+			metadata[c.zone.metadataKey] = c.zone.zone
+			o.metadata = metadata
+		}
+	}
+
+	param := vo.RegisterInstanceParam{
 		Ip:          ip,
 		Port:        uint64(port),
 		ServiceName: serviceName,
-		Weight:      10,
+		Weight:      o.weight,
 		Enable:      true,
 		Healthy:     true,
-		Ephemeral:   true,        // 设置为临时节点，心跳断开后会自动摘除
-		GroupName:   c.groupName, // ✨ 核心: 注册时使用客户端配置的分组
-	})
+		Metadata:    o.metadata,
+		Ephemeral:   o.ephemeral, // 临时节点在心跳断开后会自动摘除
+		GroupName:   group,       // ✨ 核心: 注册时使用客户端配置的分组(可通过 WithGroup 覆盖)
+	}
+	success, err := c.namingClient.RegisterInstance(param)
 	if err != nil {
-		return fmt.Errorf("failed to register service with nacos: %w", err)
+		return fmt.Errorf("failed to register service with nacos: %w", classifyError(err))
 	}
 	if !success {
-		return fmt.Errorf("nacos registration was not successful for service: %s", serviceName)
+		return fmt.Errorf("%w: registration was not successful for service: %s", ErrNacosUnavailable, serviceName)
 	}
-	logger.Logger.Printf("✅ Service '%s' registered to Nacos successfully (%s:%d)", serviceName, ip, port)
+	c.registrationsLock.Lock()
+	c.registrations[instanceKey(group, serviceName, ip, port)] = param
+	c.registrationsLock.Unlock()
+	logger.Logger.Printf("✅ Service '%s' registered to Nacos group '%s' successfully (%s:%d)", serviceName, group, ip, port)
 	return nil
 }
 
-// DeregisterServiceInstance 从 Nacos 注销一个服务实例
-func (c *Client) DeregisterServiceInstance(serviceName, ip string, port int) error {
+// DeregisterServiceInstance 从 Nacos 注销一个服务实例。
+// 注销时沿用该实例注册时的 ephemeral 标记；未经本客户端注册的实例按临时实例处理。
+// 注册时使用了 WithGroup 的实例，注销时需要传入相同的 WithGroup。
+func (c *Client) DeregisterServiceInstance(serviceName, ip string, port int, opts ...RegisterOption) error {
+	var o registerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	group := c.resolveGroup(o.group)
+	key := instanceKey(group, serviceName, ip, port)
+	c.registrationsLock.Lock()
+	registered, ok := c.registrations[key]
+	c.registrationsLock.Unlock()
+	ephemeral := true
+	if ok {
+		ephemeral = registered.Ephemeral
+	}
+
 	_, err := c.namingClient.DeregisterInstance(vo.DeregisterInstanceParam{
 		Ip:          ip,
 		Port:        uint64(port),
 		ServiceName: serviceName,
-		Ephemeral:   true,
-		GroupName:   c.groupName, // ✨ 核心: 注销时使用客户端配置的分组
+		Ephemeral:   ephemeral,
+		GroupName:   group, // ✨ 核心: 注销时使用客户端配置的分组
 	})
 	if err != nil {
 		return fmt.Errorf("failed to deregister service with nacos: %w", err)
 	}
+	c.registrationsLock.Lock()
+	delete(c.registrations, key)
+	c.registrationsLock.Unlock()
 	logger.Logger.Printf("ℹ️ Service '%s' deregistered from Nacos (%s:%d)", serviceName, ip, port)
 	return nil
 }
 
 // DiscoverServiceInstance 从 Nacos 发现一个健康的服务实例
-// 使用 Nacos 内置的负载均衡算法
-func (c *Client) DiscoverServiceInstance(serviceName string) (string, int, error) {
+// 使用 Nacos 内置的负载均衡算法；开启 WithDiscoveryCache 或 WithZoneAffinity 时
+// 从(同可用区优先的)实例列表中按权重随机选择。
+// 默认在客户端的分组中发现，可以通过 FromGroup 指定其他分组。
+// 返回的错误可以通过 errors.Is 与 ErrNoHealthyInstance、ErrServiceNotFound、ErrNacosUnavailable 比较，以决定是否重试。
+func (c *Client) DiscoverServiceInstance(serviceName string, opts ...DiscoverOption) (string, int, error) {
+	group := c.discoverGroup(opts)
+	if c.cache != nil || c.zone != nil {
+		instances, err := c.instances(serviceName, group)
+		if err != nil {
+			return "", 0, err
+		}
+		instance := pickWeighted(c.zone.preferLocal(instances))
+		return instance.Ip, int(instance.Port), nil
+	}
+
 	instance, err := c.namingClient.SelectOneHealthyInstance(vo.SelectOneHealthInstanceParam{
 		ServiceName: serviceName,
-		GroupName:   c.groupName, // ✨ 核心: 服务发现时指定分组
+		GroupName:   group, // ✨ 核心: 服务发现时指定分组
 	})
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to discover healthy instance for service '%s': %w", serviceName, err)
+		return "", 0, fmt.Errorf("failed to discover healthy instance for service '%s': %w", serviceName, classifyError(err))
 	}
 	if instance == nil {
-		return "", 0, fmt.Errorf("no healthy instance available for service '%s'", serviceName)
+		return "", 0, fmt.Errorf("%w available for service '%s'", ErrNoHealthyInstance, serviceName)
 	}
 	return instance.Ip, int(instance.Port), nil
 }
 
-// Close 关闭 Nacos 客户端连接
+// DiscoverAllInstances 从 Nacos 获取一个服务的全部健康实例
+// 适用于需要自行选择实例的场景，例如一致性哈希路由
+// 开启 WithDiscoveryCache 时返回缓存的实例列表副本
+func (c *Client) DiscoverAllInstances(serviceName string, opts ...DiscoverOption) ([]model.Instance, error) {
+	return c.instances(serviceName, c.discoverGroup(opts))
+}
+
+// instances 返回指定分组中服务的健康实例，开启缓存时优先使用缓存
+func (c *Client) instances(serviceName, group string) ([]model.Instance, error) {
+	if c.cache != nil {
+		return c.cachedInstances(serviceName, group)
+	}
+	return c.selectInstances(serviceName, group)
+}
+
+// selectInstances 实时从 Nacos 查询服务的健康实例
+func (c *Client) selectInstances(serviceName, group string) ([]model.Instance, error) {
+	instances, err := c.namingClient.SelectInstances(vo.SelectInstancesParam{
+		ServiceName: serviceName,
+		GroupName:   group,
+		HealthyOnly: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover instances for service '%s': %w", serviceName, classifyError(err))
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("%w available for service '%s'", ErrNoHealthyInstance, serviceName)
+	}
+	return instances, nil
+}
+
+// Close 取消所有服务变更订阅，并关闭 Nacos 命名客户端持有的 gRPC 连接和后台 goroutine。
+// 关闭后客户端不可再使用。
 func (c *Client) Close() {
-	if c.namingClient != nil {
-		// Nacos Go SDK v2.x.x 没有显式的 Close 方法
-		// 临时节点会在心跳停止后自动过期
-		logger.Logger.Println("ℹ️ Nacos client does not require explicit closing. Ephemeral nodes will expire.")
+	if c.namingClient == nil {
+		return
+	}
+	c.stopOnce.Do(func() { close(c.stopCh) })
+	if c.cache != nil {
+		c.cache.mu.Lock()
+		subscriptions := c.cache.subscriptions
+		c.cache.subscriptions = make(map[string]*vo.SubscribeParam)
+		c.cache.mu.Unlock()
+		for _, param := range subscriptions {
+			if err := c.namingClient.Unsubscribe(param); err != nil {
+				logger.Logger.Warn().Err(err).Str("service", param.ServiceName).Str("group", param.GroupName).Msg("⚠️ Failed to unsubscribe from service changes")
+			}
+		}
 	}
+	c.namingClient.CloseClient()
+	logger.Logger.Println("ℹ️ Nacos naming client closed.")
 }
@@ -0,0 +1,30 @@
+package nacos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNoHealthyInstance 服务存在，但当前没有可用的健康实例，通常稍后重试即可
+	ErrNoHealthyInstance = errors.New("nacos: no healthy instance")
+	// ErrServiceNotFound 服务在 Nacos 中不存在，通常是服务名或分组配置错误，重试无意义
+	ErrServiceNotFound = errors.New("nacos: service not found")
+	// ErrNacosUnavailable 无法与 Nacos 服务器正常通信
+	ErrNacosUnavailable = errors.New("nacos: server unavailable")
+)
+
+// classifyError 将 SDK 返回的错误归类为上述哨兵错误之一，并保留原始错误。
+// SDK 没有导出错误类型，只能根据错误信息判断；无法识别的错误按 Nacos 不可用处理。
+func classifyError(err error) error {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "instance list is empty"), strings.Contains(msg, "no healthy"):
+		return fmt.Errorf("%w: %w", ErrNoHealthyInstance, err)
+	case strings.Contains(msg, "not found"), strings.Contains(msg, "not exist"):
+		return fmt.Errorf("%w: %w", ErrServiceNotFound, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrNacosUnavailable, err)
+	}
+}
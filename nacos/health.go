@@ -0,0 +1,136 @@
+package nacos
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/model"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+const (
+	defaultProbeTimeout  = time.Second
+	defaultProbeCacheTTL = 5 * time.Second
+)
+
+// healthProber 对实例执行主动健康探测，并在短时间内缓存探测结果
+type healthProber struct {
+	client   *http.Client
+	cacheTTL time.Duration
+
+	mu sync.Mutex
+	// 按服务与探测路径分组的探测结果，组内以实例地址为 key
+	results map[probeTarget]map[string]probeResult
+}
+
+type probeTarget struct {
+	service    string
+	healthPath string
+}
+
+type probeResult struct {
+	healthy   bool
+	checkedAt time.Time
+}
+
+func newHealthProber(timeout, cacheTTL time.Duration) *healthProber {
+	return &healthProber{
+		client:   &http.Client{Timeout: timeout},
+		cacheTTL: cacheTTL,
+		results:  make(map[probeTarget]map[string]probeResult),
+	}
+}
+
+// check 并发探测服务的所有实例，返回与 addrs 一一对应的探测结果，缓存未过期的实例直接使用缓存结果。
+// 总耗时不超过一次探测超时，与实例数量无关。已经不在 addrs 中的实例的缓存结果会被清理。
+func (p *healthProber) check(ctx context.Context, service, healthPath string, addrs []string) []bool {
+	target := probeTarget{service: service, healthPath: healthPath}
+	healthy := make([]bool, len(addrs))
+
+	p.mu.Lock()
+	cached := p.results[target]
+	fresh := make(map[string]probeResult, len(addrs))
+	var pending []int
+	for i, addr := range addrs {
+		if r, ok := cached[addr]; ok && time.Since(r.checkedAt) < p.cacheTTL {
+			healthy[i] = r.healthy
+			fresh[addr] = r
+			continue
+		}
+		pending = append(pending, i)
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, i := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			healthy[i] = p.probe(ctx, addrs[i], healthPath)
+		}()
+	}
+	wg.Wait()
+
+	now := time.Now()
+	for _, i := range pending {
+		fresh[addrs[i]] = probeResult{healthy: healthy[i], checkedAt: now}
+	}
+	// 整体替换该服务的缓存，下线实例的结果随之被清理
+	p.mu.Lock()
+	p.results[target] = fresh
+	p.mu.Unlock()
+	return healthy
+}
+
+// probe 发送一次 HTTP GET，2xx 视为健康
+func (p *healthProber) probe(ctx context.Context, addr, healthPath string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+healthPath, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		logger.Logger.Warn().Err(err).Str("instance", addr).Msg("⚠️ Health probe failed")
+		return false
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Logger.Warn().Int("status", resp.StatusCode).Str("instance", addr).Msg("⚠️ Health probe returned non-2xx status")
+		return false
+	}
+	return true
+}
+
+// DiscoverHealthyInstance 从 Nacos 获取健康实例，并发地对它们执行主动健康探测(HTTP GET healthPath)，
+// 按同可用区优先的顺序返回第一个探测通过的实例。它用于弥补实例宕机到 Nacos 感知之间的时间差。
+// 探测结果会被短暂缓存，探测超时与缓存时长可以通过 WithHealthProbe 配置。
+func (c *Client) DiscoverHealthyInstance(serviceName, healthPath string, opts ...DiscoverOption) (string, int, error) {
+	instances, err := c.DiscoverAllInstances(serviceName, opts...)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// 同可用区的实例优先探测；组内随机顺序，避免所有调用方都集中到同一个实例
+	local, others := c.zone.partition(instances)
+	for _, group := range [][]model.Instance{local, others} {
+		rand.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+	}
+	ordered := append(local, others...)
+	addrs := make([]string, len(ordered))
+	for i, ins := range ordered {
+		addrs[i] = net.JoinHostPort(ins.Ip, strconv.FormatUint(ins.Port, 10))
+	}
+	healthy := c.prober.check(context.Background(), serviceName, healthPath, addrs)
+	for i, ins := range ordered {
+		if healthy[i] {
+			return ins.Ip, int(ins.Port), nil
+		}
+	}
+	return "", 0, fmt.Errorf("%w: no instance of service '%s' passed health probe %s", ErrNoHealthyInstance, serviceName, healthPath)
+}
@@ -0,0 +1,105 @@
+package nacos
+
+import "time"
+
+// RegisterOption 用于定制服务实例的注册参数
+type RegisterOption func(*registerOptions)
+
+type registerOptions struct {
+	ephemeral bool
+	weight    float64
+	metadata  map[string]string
+	group     string
+}
+
+// defaultRegisterOptions 返回保持历史行为的默认注册参数: 临时实例，权重 10
+func defaultRegisterOptions() registerOptions {
+	return registerOptions{
+		ephemeral: true,
+		weight:    10,
+	}
+}
+
+// WithEphemeral 设置实例是否为临时实例。
+// 临时实例依赖客户端心跳，心跳断开后会被自动摘除；持久实例(false)会一直保留，直到被显式注销，
+// 适用于部署在稳定 VIP 之后的遗留服务。
+func WithEphemeral(ephemeral bool) RegisterOption {
+	return func(o *registerOptions) {
+		o.ephemeral = ephemeral
+	}
+}
+
+// WithWeight 设置实例权重
+func WithWeight(weight float64) RegisterOption {
+	return func(o *registerOptions) {
+		o.weight = weight
+	}
+}
+
+// WithMetadata 设置实例元数据
+func WithMetadata(metadata map[string]string) RegisterOption {
+	return func(o *registerOptions) {
+		o.metadata = metadata
+	}
+}
+
+// WithGroup 在指定分组而不是客户端的默认分组中注册(或注销)实例
+func WithGroup(group string) RegisterOption {
+	return func(o *registerOptions) {
+		o.group = group
+	}
+}
+
+// DiscoverOption 用于定制单次服务发现的参数
+type DiscoverOption func(*discoverOptions)
+
+type discoverOptions struct {
+	group string
+}
+
+// FromGroup 在指定分组而不是客户端的默认分组中发现服务，
+// 适用于网关等需要调用注册在多个分组中的服务的场景
+func FromGroup(group string) DiscoverOption {
+	return func(o *discoverOptions) {
+		o.group = group
+	}
+}
+
+// resolveGroup 返回单次调用实际使用的分组，未指定时使用客户端的默认分组
+func (c *Client) resolveGroup(group string) string {
+	if group == "" {
+		return c.groupName
+	}
+	return group
+}
+
+// discoverGroup 从发现选项中解析分组
+func (c *Client) discoverGroup(opts []DiscoverOption) string {
+	var o discoverOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return c.resolveGroup(o.group)
+}
+
+// ClientOption 用于定制 Nacos 客户端的行为
+type ClientOption func(*Client)
+
+// WithHealthProbe 配置 DiscoverHealthyInstance 的探测超时与探测结果缓存时长，
+// 默认分别为 1 秒和 5 秒
+func WithHealthProbe(timeout, cacheTTL time.Duration) ClientOption {
+	return func(c *Client) {
+		c.prober = newHealthProber(timeout, cacheTTL)
+	}
+}
+
+// WithDiscoveryCache 为服务发现开启实例列表缓存，ttl 为缓存有效期，<= 0 时使用默认的 3 秒。
+// 未开启时每次发现都会实时请求 Nacos。
+func WithDiscoveryCache(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		if ttl <= 0 {
+			ttl = defaultDiscoveryCacheTTL
+		}
+		c.cache = newDiscoveryCache(ttl)
+	}
+}
@@ -0,0 +1,34 @@
+package nacos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+)
+
+// Ping 通过查询命名空间下的服务列表(只取一条)检查与 Nacos 服务器的连接是否正常。
+// 失败时返回的错误包装了 ErrNacosUnavailable，可以用来区分"Nacos 不可用"和"某个服务没有实例"。
+// SDK 调用本身不支持 context，ctx 到期时 Ping 立即返回，后台的请求仍会在 SDK 超时后结束。
+func (c *Client) Ping(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.namingClient.GetAllServicesInfo(vo.GetAllServiceInfoParam{
+			NameSpace: c.namespaceId,
+			GroupName: c.groupName,
+			PageNo:    1,
+			PageSize:  1,
+		})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%w: ping failed: %w", ErrNacosUnavailable, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: ping: %w", ErrNacosUnavailable, ctx.Err())
+	}
+}
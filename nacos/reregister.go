@@ -0,0 +1,56 @@
+package nacos
+
+import (
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+// WithReRegistration 开启后台重新注册: 每隔 interval 重新提交一次本客户端注册过的全部实例。
+// Nacos 服务器重启或会话失效时临时实例可能被摘除且不会自动恢复，重新注册可以让实例自愈；
+// 注册是幂等的，对已存在的实例没有副作用。后台循环在 Close 时停止。
+func WithReRegistration(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.reRegisterInterval = interval
+	}
+}
+
+// reRegisterLoop 周期性地重新注册所有实例，直到客户端关闭
+func (c *Client) reRegisterLoop() {
+	ticker := time.NewTicker(c.reRegisterInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.reRegisterAll()
+		}
+	}
+}
+
+func (c *Client) reRegisterAll() {
+	c.registrationsLock.Lock()
+	params := make([]vo.RegisterInstanceParam, 0, len(c.registrations))
+	for _, param := range c.registrations {
+		params = append(params, param)
+	}
+	c.registrationsLock.Unlock()
+
+	for _, param := range params {
+		// 快照之后实例可能已经被注销，避免把它重新注册回来
+		c.registrationsLock.Lock()
+		_, ok := c.registrations[instanceKey(param.GroupName, param.ServiceName, param.Ip, int(param.Port))]
+		c.registrationsLock.Unlock()
+		if !ok {
+			continue
+		}
+		if _, err := c.namingClient.RegisterInstance(param); err != nil {
+			logger.Logger.Warn().Err(err).Str("service", param.ServiceName).
+				Msgf("⚠️ Failed to re-register instance %s:%d, will retry", param.Ip, param.Port)
+			continue
+		}
+		logger.Logger.Debug().Str("service", param.ServiceName).Msgf("re-registered instance %s:%d", param.Ip, param.Port)
+	}
+}
@@ -0,0 +1,55 @@
+package nacos
+
+import (
+	"os"
+
+	"github.com/nacos-group/nacos-sdk-go/v2/model"
+)
+
+// zoneAffinity 描述同可用区优先的实例选择策略
+type zoneAffinity struct {
+	// metadataKey 为实例元数据中表示可用区的键，例如 zone
+	metadataKey string
+	// zone 为调用方自身所在的可用区
+	zone string
+}
+
+// WithZoneAffinity 开启同可用区优先的服务发现。
+// metadataKey 为实例元数据中表示可用区的键(例如 zone)，调用方自身的可用区读取自 NEXUS_ZONE 环境变量；
+// 环境变量未设置时该选项不生效。开启后本客户端注册的实例也会在元数据中带上自身的可用区。
+//
+// DiscoverServiceInstance 与 DiscoverHealthyInstance 会优先在同可用区的健康实例中选择，
+// 只有当同可用区没有任何健康实例时，才会回退到全部可用区的健康实例，以可用性优先于跨区成本。
+func WithZoneAffinity(metadataKey string) ClientOption {
+	return func(c *Client) {
+		zone := os.Getenv("NEXUS_ZONE")
+		if metadataKey == "" || zone == "" {
+			return
+		}
+		c.zone = &zoneAffinity{metadataKey: metadataKey, zone: zone}
+	}
+}
+
+// preferLocal 返回同可用区的实例，没有时返回全部实例
+func (z *zoneAffinity) preferLocal(instances []model.Instance) []model.Instance {
+	local, _ := z.partition(instances)
+	if len(local) == 0 {
+		return instances
+	}
+	return local
+}
+
+// partition 将实例分为同可用区与其他可用区两组，未开启可用区优先时全部视为同可用区
+func (z *zoneAffinity) partition(instances []model.Instance) (local, others []model.Instance) {
+	if z == nil {
+		return instances, nil
+	}
+	for _, ins := range instances {
+		if ins.Metadata[z.metadataKey] == z.zone {
+			local = append(local, ins)
+		} else {
+			others = append(others, ins)
+		}
+	}
+	return local, others
+}
@@ -38,6 +38,9 @@ func NewClient(redisAddrs string) (*Client, error) {
 		})
 	}
 
+	// 为每条命令创建子 Span，使 Redis 耗时出现在请求链路中
+	rdb.AddHook(newTracingHook())
+
 	if err := rdb.Ping(context.Background()).Err(); err != nil {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
@@ -83,7 +86,8 @@ func (c *Client) RunScript(ctx context.Context, scriptName string, keys []string
 	return result, nil
 }
 
-// GetClient 返回底层的 redis 客户端，以便执行其他通用命令
+// GetClient 返回底层的 redis 客户端，以便执行其他通用命令。
+// 常用命令请优先使用 Get、Set、Del、Incr 等封装方法。
 func (c *Client) GetClient() redis.UniversalClient {
 	return c.rdb
 }
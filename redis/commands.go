@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrKeyNotFound 在 Get 读取的 key 不存在时返回
+var ErrKeyNotFound = errors.New("redis: key not found")
+
+// Get 读取 key 的字符串值，key 不存在时返回 ErrKeyNotFound
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	val, err := c.rdb.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", fmt.Errorf("%w: '%s'", ErrKeyNotFound, key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get '%s': %w", key, err)
+	}
+	return val, nil
+}
+
+// Set 写入 key，ttl 为 0 时不设置过期时间
+func (c *Client) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := c.rdb.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set '%s': %w", key, err)
+	}
+	return nil
+}
+
+// Del 删除一个或多个 key，返回实际删除的数量。
+// 集群模式下多个 key 必须位于同一个哈希槽(可以使用 {hashtag})。
+func (c *Client) Del(ctx context.Context, keys ...string) (int64, error) {
+	n, err := c.rdb.Del(ctx, keys...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete %v: %w", keys, err)
+	}
+	return n, nil
+}
+
+// Incr 将 key 的整数值加一并返回新值，key 不存在时从 0 开始
+func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
+	n, err := c.rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to incr '%s': %w", key, err)
+	}
+	return n, nil
+}
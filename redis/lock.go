@@ -0,0 +1,140 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wangyingjie930/nexus-pkg/idgen"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+// ErrLockNotHeld 在释放锁时锁已经过期或被其他持有者获取时返回
+var ErrLockNotHeld = errors.New("redis: lock not held")
+
+const (
+	releaseScriptName = "nexus:lock:release"
+	renewScriptName   = "nexus:lock:renew"
+
+	// lockRetryInterval 是锁被占用时的最长重试间隔
+	lockRetryInterval = 100 * time.Millisecond
+)
+
+// releaseScript 只有在锁的值仍为自己的 token 时才删除，避免误删其他持有者的锁
+const releaseScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+
+// renewScript 只有在锁仍由自己持有时才延长过期时间
+const renewScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`
+
+// Lock 基于单个 Redis 实例(或集群中 key 所在的分片)获取分布式锁，阻塞直到获取成功或 ctx 被取消。
+// 锁的值是一个唯一 token，释放时通过 Lua 脚本比较 token 后再删除，只会释放自己持有的锁。
+//
+// 持有期间每隔 ttl/3 自动续期，进程崩溃时锁最多在 ttl 后自动释放。续期失败(锁已过期被他人获取)时记录错误日志，
+// 对正确性要求高的场景请改用带 fencing token 的 zookeeper.DistributedLock。
+//
+//	unlock, err := client.Lock(ctx, "lock:order:"+orderID, 10*time.Second)
+//	if err != nil {
+//		return err
+//	}
+//	defer unlock()
+func (c *Client) Lock(ctx context.Context, key string, ttl time.Duration) (unlock func() error, err error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("invalid lock ttl %s", ttl)
+	}
+	c.loadBuiltinScript(releaseScriptName, releaseScript)
+	c.loadBuiltinScript(renewScriptName, renewScript)
+
+	token := idgen.New()
+	retry := min(ttl/10, lockRetryInterval)
+	for {
+		acquired, err := c.rdb.SetNX(ctx, key, token, ttl).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire lock '%s': %w", key, err)
+		}
+		if acquired {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to acquire lock '%s': %w", key, ctx.Err())
+		case <-time.After(retry):
+		}
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.renewLock(key, token, ttl, stop)
+	}()
+
+	var once sync.Once
+	var unlockErr error
+	unlock = func() error {
+		once.Do(func() {
+			close(stop)
+			<-done
+			unlockErr = c.releaseLock(key, token)
+		})
+		return unlockErr
+	}
+	return unlock, nil
+}
+
+// renewLock 周期性地延长锁的过期时间，直到 stop 被关闭或锁已不再由自己持有
+func (c *Client) renewLock(key, token string, ttl time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+			result, err := c.RunScript(ctx, renewScriptName, []string{key}, token, ttl.Milliseconds())
+			cancel()
+			if err != nil {
+				// 网络抖动时在下一个周期重试，锁在 ttl 内仍然有效
+				logger.Logger.Warn().Err(err).Str("key", key).Msg("⚠️ Failed to renew redis lock, will retry")
+				continue
+			}
+			if n, _ := result.(int64); n == 0 {
+				logger.Logger.Error().Str("key", key).Msg("❌ Redis lock lost before unlock, renewal stopped")
+				return
+			}
+		}
+	}
+}
+
+// releaseLock 比较 token 后删除锁
+func (c *Client) releaseLock(key, token string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	result, err := c.RunScript(ctx, releaseScriptName, []string{key}, token)
+	if err != nil {
+		return fmt.Errorf("failed to release lock '%s': %w", key, err)
+	}
+	if n, _ := result.(int64); n == 0 {
+		return fmt.Errorf("%w: '%s'", ErrLockNotHeld, key)
+	}
+	return nil
+}
+
+// loadBuiltinScript 注册包内置的 Lua 脚本，已注册时不做任何事
+func (c *Client) loadBuiltinScript(scriptName, content string) {
+	if _, loaded := c.scripts.Load(scriptName); loaded {
+		return
+	}
+	c.scripts.LoadOrStore(scriptName, redis.NewScript(content))
+}
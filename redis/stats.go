@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolStats 是连接池的统计信息
+type PoolStats struct {
+	Hits       uint32 // 从池中复用到空闲连接的次数
+	Misses     uint32 // 池中没有空闲连接、需要新建连接的次数
+	Timeouts   uint32 // 等待可用连接超时的次数，持续增长说明连接池过小
+	TotalConns uint32 // 当前连接总数
+	IdleConns  uint32 // 当前空闲连接数
+	StaleConns uint32 // 被移除的失效连接数
+}
+
+// Stats 返回底层连接池的统计信息。集群模式下为所有节点连接池的汇总。
+func (c *Client) Stats() PoolStats {
+	s := c.rdb.PoolStats()
+	return PoolStats{
+		Hits:       s.Hits,
+		Misses:     s.Misses,
+		Timeouts:   s.Timeouts,
+		TotalConns: s.TotalConns,
+		IdleConns:  s.IdleConns,
+		StaleConns: s.StaleConns,
+	}
+}
+
+// Ping 检查与 Redis 的连接是否正常，适合用作健康检查
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.rdb.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis ping failed: %w", err)
+	}
+	return nil
+}
+
+// RegisterMetrics 将连接池统计信息以 redis_pool_* 指标的形式注册到调用方的 Registerer 中。
+// 指标在每次抓取时从连接池实时读取。重复注册是安全的，已注册的指标会被忽略。
+func (c *Client) RegisterMetrics(reg prometheus.Registerer) error {
+	if err := reg.Register(poolCollector{client: c}); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	poolHitsDesc       = prometheus.NewDesc("redis_pool_hits_total", "Number of times a free connection was found in the pool.", nil, nil)
+	poolMissesDesc     = prometheus.NewDesc("redis_pool_misses_total", "Number of times a free connection was not found in the pool.", nil, nil)
+	poolTimeoutsDesc   = prometheus.NewDesc("redis_pool_timeouts_total", "Number of times a wait for a pool connection timed out.", nil, nil)
+	poolTotalConnsDesc = prometheus.NewDesc("redis_pool_total_conns", "Number of connections in the pool.", nil, nil)
+	poolIdleConnsDesc  = prometheus.NewDesc("redis_pool_idle_conns", "Number of idle connections in the pool.", nil, nil)
+	poolStaleConnsDesc = prometheus.NewDesc("redis_pool_stale_conns_total", "Number of stale connections removed from the pool.", nil, nil)
+)
+
+// poolCollector 在抓取时读取连接池统计信息
+type poolCollector struct {
+	client *Client
+}
+
+func (p poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolHitsDesc
+	ch <- poolMissesDesc
+	ch <- poolTimeoutsDesc
+	ch <- poolTotalConnsDesc
+	ch <- poolIdleConnsDesc
+	ch <- poolStaleConnsDesc
+}
+
+func (p poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := p.client.Stats()
+	ch <- prometheus.MustNewConstMetric(poolHitsDesc, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(poolMissesDesc, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(poolTimeoutsDesc, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(poolTotalConnsDesc, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(poolIdleConnsDesc, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(poolStaleConnsDesc, prometheus.CounterValue, float64(s.StaleConns))
+}
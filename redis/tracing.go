@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingHook 为每条 Redis 命令(及每个 pipeline)创建一个子 Span。
+// 出于安全考虑只记录命令名和参数个数，不记录 key 与 value 的内容。
+type tracingHook struct {
+	tracer trace.Tracer
+}
+
+var _ redis.Hook = tracingHook{}
+
+func newTracingHook() tracingHook {
+	return tracingHook{tracer: otel.Tracer("nexus-pkg/redis")}
+}
+
+func (h tracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h tracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, "redis."+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				semconv.DBOperationName(cmd.Name()),
+				attribute.Int("db.redis.num_args", len(cmd.Args())-1),
+			))
+		defer span.End()
+
+		err := next(ctx, cmd)
+		recordRedisError(span, err)
+		return err
+	}
+}
+
+func (h tracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := h.tracer.Start(ctx, "redis.pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				semconv.DBOperationName("pipeline"),
+				attribute.Int("db.redis.num_cmd", len(cmds)),
+			))
+		defer span.End()
+
+		err := next(ctx, cmds)
+		recordRedisError(span, err)
+		return err
+	}
+}
+
+// recordRedisError 记录命令错误，key 不存在(redis.Nil)不视为错误
+func recordRedisError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
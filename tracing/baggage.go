@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/wangyingjie930/nexus-pkg/logger"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// 约定的 baggage 键
+//
+// baggage 会以明文形式随 HTTP 请求头(baggage)和 Kafka 消息头传递给链路上的所有下游，包括第三方服务，
+// 并可能被 logger.RegisterBaggageKeys 写入日志。因此以下内容被视为敏感信息，禁止放入 baggage:
+// 用户 ID 以外的个人信息(手机号、邮箱、证件号、地址)、认证凭据(token、密码、API Key、Cookie)以及支付信息。
+// 需要跨服务传递这些信息时请放在经过鉴权的请求体中。
+const (
+	// BaggageTenantID 租户 ID
+	BaggageTenantID = "tenant_id"
+	// BaggageRequestID 业务请求 ID，用于关联同一次请求在各服务中的日志
+	BaggageRequestID = "request_id"
+)
+
+// WithBaggage 返回一个在原有 baggage 基础上设置了 key=value 的 context。
+// value 会原样传递，无需调用方做百分号编码；key 不合法时记录警告并返回原 context。
+// baggage 通过全局 Propagator 在 httpclient、middleware.Tracing 与 mq 的 Inject/ExtractTraceContext 中自动传播，
+// 不要放入敏感信息，见 BaggageTenantID 上方的说明。
+func WithBaggage(ctx context.Context, key, value string) context.Context {
+	member, err := baggage.NewMemberRaw(key, value)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("⚠️ Invalid baggage member, ignored")
+		return ctx
+	}
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		logger.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("⚠️ Failed to set baggage member, ignored")
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// BaggageValue 返回 context 中 key 对应的 baggage 值，不存在时返回空字符串
+func BaggageValue(ctx context.Context, key string) string {
+	return baggage.FromContext(ctx).Member(key).Value()
+}
@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Option 用于定制 TracerProvider 的初始化行为
+type Option func(*options)
+
+type options struct {
+	sampler       sdktrace.Sampler
+	resourceAttrs []attribute.KeyValue
+
+	// 批处理 Span 处理器的参数
+	batchTimeout       time.Duration
+	maxExportBatchSize int
+	maxQueueSize       int // 为 0 时使用 SDK 默认值(可由 OTEL_BSP_MAX_QUEUE_SIZE 环境变量覆盖)
+}
+
+// defaultOptions 返回保持历史行为的默认选项
+func defaultOptions() options {
+	return options{
+		// 默认始终采样，保持与之前版本一致
+		sampler:            sdktrace.AlwaysSample(),
+		batchTimeout:       5 * time.Second,
+		maxExportBatchSize: 512,
+	}
+}
+
+// WithSampler 使用自定义的采样器
+func WithSampler(sampler sdktrace.Sampler) Option {
+	return func(o *options) {
+		o.sampler = sampler
+	}
+}
+
+// WithSampleRatio 按比例采样，ratio 取值范围为 (0, 1]。
+// 使用 ParentBased 包装，保证同一条链路上的采样决策与上游保持一致。
+func WithSampleRatio(ratio float64) Option {
+	return WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)))
+}
+
+// WithResourceAttributes 为 Resource 追加自定义属性，例如 pod 名称、可用区等
+func WithResourceAttributes(attrs map[string]string) Option {
+	return func(o *options) {
+		for k, v := range attrs {
+			o.resourceAttrs = append(o.resourceAttrs, attribute.String(k, v))
+		}
+	}
+}
+
+// WithServiceVersion 设置 service.version 资源属性
+func WithServiceVersion(version string) Option {
+	return func(o *options) {
+		o.resourceAttrs = append(o.resourceAttrs, semconv.ServiceVersionKey.String(version))
+	}
+}
+
+// WithEnvironment 设置 deployment.environment 资源属性
+func WithEnvironment(env string) Option {
+	return func(o *options) {
+		o.resourceAttrs = append(o.resourceAttrs, semconv.DeploymentEnvironmentKey.String(env))
+	}
+}
+
+// WithBatchTimeout 设置批处理导出的最长等待时间，默认 5 秒。开发环境调小可以更快地在 Jaeger 中看到 Span
+func WithBatchTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.batchTimeout = timeout
+	}
+}
+
+// WithMaxExportBatchSize 设置单次导出的最大 Span 数，默认 512。高负载下调大可以减少导出次数
+func WithMaxExportBatchSize(size int) Option {
+	return func(o *options) {
+		o.maxExportBatchSize = size
+	}
+}
+
+// WithMaxQueueSize 设置等待导出的 Span 队列容量，默认 2048。队列满时新的 Span 会被丢弃，调大可以吸收流量尖峰
+func WithMaxQueueSize(size int) Option {
+	return func(o *options) {
+		o.maxQueueSize = size
+	}
+}
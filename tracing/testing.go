@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// NewTestTracerProvider 创建一个把 Span 同步写入内存的 TracerProvider，供单元测试断言 Span 的名称、属性、事件与状态:
+//
+//	tp, exporter := tracing.NewTestTracerProvider()
+//	defer tp.Shutdown(context.Background())
+//	// ... 调用被测代码 ...
+//	spans := exporter.GetSpans()
+//
+// 由于被测代码通常通过 otel.Tracer 获取 tracer，它会把返回的 TracerProvider 和 Propagator 设置为全局的，
+// 因此使用它的测试不应并行运行。它不影响 InitTracerProvider 的幂等状态。
+func NewTestTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		// 同步导出，Span 结束后立即可见，无需等待批处理
+		sdktrace.WithSyncer(exporter),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp, exporter
+}
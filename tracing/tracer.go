@@ -3,9 +3,12 @@ package tracing
 
 import (
 	"context"
+	"github.com/rs/zerolog"
 	"github.com/wangyingjie930/nexus-pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
-	"time"
+	"os"
+	"sync"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/jaeger"
@@ -15,39 +18,139 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
+var (
+	providerLock sync.Mutex
+	// 当前进程内已注册的全局 TracerProvider，保证重复初始化时不会创建多个 exporter
+	activeProvider *sdktrace.TracerProvider
+)
+
 // InitTracerProvider initializes and registers a Jaeger TraceProvider.
-func InitTracerProvider(serviceName, jaegerEndpoint string) (*sdktrace.TracerProvider, error) {
+// 它是 InitTracerProviderWithExporter 的 Jaeger 便捷包装。
+// 重复调用时直接返回已注册的 TracerProvider。
+func InitTracerProvider(serviceName, jaegerEndpoint string, opts ...Option) (*sdktrace.TracerProvider, error) {
+	if tp := currentProvider(); tp != nil {
+		logger.Logger.Printf("Tracing already initialized, reusing existing tracer provider for service '%s'", serviceName)
+		return tp, nil
+	}
+
 	// 创建 Jaeger Exporter，用于将 Span 数据发送到 Jaeger
 	exporter, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(jaegerEndpoint)))
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建 TracerProvider，它是 OTel SDK 的核心组件
-	tp := sdktrace.NewTracerProvider(
-		// 始终对 Span 进行采样，在生产环境中应使用更复杂的采样策略
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		// 使用批处理 Span 处理器，提高性能
-		sdktrace.WithBatcher(exporter,
-			sdktrace.WithBatchTimeout(5*time.Second),
-			sdktrace.WithMaxExportBatchSize(512),
-		),
+	tp, err := InitTracerProviderWithExporter(serviceName, exporter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	logger.Logger.Printf("Tracing initialized for service '%s' exporting to '%s'", serviceName, jaegerEndpoint)
+	return tp, nil
+}
+
+// InitTracerProviderWithExporter 使用调用方提供的 SpanExporter 初始化并注册全局 TracerProvider。
+// Jaeger exporter 已被上游废弃，迁移到 OTLP collector 时可以这样使用:
+//
+//	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint("otel-collector:4317"), otlptracegrpc.WithInsecure())
+//	// 或 otlptracehttp.New(ctx, otlptracehttp.WithEndpoint("otel-collector:4318"))
+//	tp, err := tracing.InitTracerProviderWithExporter("order-service", exporter)
+//
+// 该函数是幂等的: 如果进程内已经注册过 TracerProvider，则关闭传入的 exporter 并返回已有的实例，
+// 以避免 v1 StartService 与 v2 Application 共存时出现重复的 exporter。
+func InitTracerProviderWithExporter(serviceName string, exporter sdktrace.SpanExporter, opts ...Option) (*sdktrace.TracerProvider, error) {
+	return registerProvider(serviceName, exporter, opts...)
+}
+
+// InitNonExportingTracerProvider 初始化一个不导出任何数据的 TracerProvider，用于没有 Jaeger 的本地开发环境。
+// 它照常生成有效的 trace_id/span_id 并设置全局 Propagator，上下游传播与日志关联都不受影响，只是 Span 不会被发送出去。
+// 与 InitTracerProviderWithExporter 一样是幂等的。
+func InitNonExportingTracerProvider(serviceName string, opts ...Option) (*sdktrace.TracerProvider, error) {
+	tp, err := registerProvider(serviceName, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	logger.Logger.Printf("Tracing initialized for service '%s' without exporter", serviceName)
+	return tp, nil
+}
+
+// registerProvider 创建并注册全局 TracerProvider，exporter 为 nil 时不导出 Span
+func registerProvider(serviceName string, exporter sdktrace.SpanExporter, opts ...Option) (*sdktrace.TracerProvider, error) {
+	providerLock.Lock()
+	defer providerLock.Unlock()
+	if activeProvider != nil {
+		if exporter == nil {
+			return activeProvider, nil
+		}
+		logger.Logger.Warn().Msgf("Tracing already initialized, discarding duplicate exporter for service '%s'", serviceName)
+		if err := exporter.Shutdown(context.Background()); err != nil {
+			return nil, err
+		}
+		return activeProvider, nil
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	res, err := buildResource(serviceName, o.resourceAttrs)
+	if err != nil {
+		return nil, err
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		// 默认始终对 Span 进行采样，生产环境可通过 WithSampleRatio 降低采样率
+		sdktrace.WithSampler(o.sampler),
 		// 设置服务名等资源属性，这对于在 Jaeger UI 中识别服务至关重要
-		sdktrace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(serviceName),
-		)),
-	)
+		sdktrace.WithResource(res),
+	}
+	if exporter != nil {
+		// 使用批处理 Span 处理器，提高性能；参数可通过 WithBatchTimeout 等选项调整
+		batchOpts := []sdktrace.BatchSpanProcessorOption{
+			sdktrace.WithBatchTimeout(o.batchTimeout),
+			sdktrace.WithMaxExportBatchSize(o.maxExportBatchSize),
+		}
+		if o.maxQueueSize > 0 {
+			batchOpts = append(batchOpts, sdktrace.WithMaxQueueSize(o.maxQueueSize))
+		}
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter, batchOpts...))
+	}
+
+	// 创建 TracerProvider，它是 OTel SDK 的核心组件
+	tp := sdktrace.NewTracerProvider(providerOpts...)
 
 	// 将我们创建的 TracerProvider 设置为全局的
 	otel.SetTracerProvider(tp)
 	// 设置全局的 TextMapPropagator，用于在服务间传递上下文
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
-	logger.Logger.Printf("Tracing initialized for service '%s' exporting to '%s'", serviceName, jaegerEndpoint)
+	activeProvider = tp
 	return tp, nil
 }
 
+// buildResource 构建 Resource，优先级从低到高依次为:
+// 主机名 < OTEL_RESOURCE_ATTRIBUTES/OTEL_SERVICE_NAME 环境变量 < 显式传入的属性 < serviceName。
+// 这样 Kubernetes 通过环境变量注入的属性可以自动生效，同时代码中的设置始终优先。
+func buildResource(serviceName string, attrs []attribute.KeyValue) (*resource.Resource, error) {
+	defaults := resource.Empty()
+	if host, err := os.Hostname(); err == nil {
+		defaults = resource.NewWithAttributes(semconv.SchemaURL, semconv.HostNameKey.String(host))
+	}
+	res, err := resource.Merge(defaults, resource.Environment())
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := append(append([]attribute.KeyValue{}, attrs...), semconv.ServiceNameKey.String(serviceName))
+	return resource.Merge(res, resource.NewWithAttributes(semconv.SchemaURL, explicit...))
+}
+
+// currentProvider 返回已注册的 TracerProvider，尚未初始化时返回 nil
+func currentProvider() *sdktrace.TracerProvider {
+	providerLock.Lock()
+	defer providerLock.Unlock()
+	return activeProvider
+}
+
 // GetTraceIDFromContext 从 Context 中提取 Trace ID 字符串
 func GetTraceIDFromContext(ctx context.Context) string {
 	spanCtx := trace.SpanContextFromContext(ctx)
@@ -56,3 +159,10 @@ func GetTraceIDFromContext(ctx context.Context) string {
 	}
 	return ""
 }
+
+// StartSpanWithLogger 启动一个子 Span，并返回已经带有该子 Span 的 trace_id/span_id 的 logger。
+// 它替代了 tracer.Start 之后再调用 logger.Ctx 的样板代码，保证日志关联的是子 Span 而不是父 Span。
+func StartSpanWithLogger(ctx context.Context, tracer trace.Tracer, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span, *zerolog.Logger) {
+	ctx, span := tracer.Start(ctx, name, opts...)
+	return ctx, span, logger.Ctx(ctx)
+}
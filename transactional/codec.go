@@ -0,0 +1,37 @@
+package transactional
+
+import (
+	"encoding/json"
+)
+
+// Codec 负责把业务对象序列化为消息体，并声明消息体的内容类型。
+// 默认使用 JSONCodec；需要 protobuf、avro 等格式时实现该接口并通过 WithCodec 注入。
+type Codec interface {
+	// ContentType 返回消息体的 MIME 类型，例如 application/json，会随消息写入 content-type 消息头
+	ContentType() string
+	// Marshal 将对象序列化为消息体
+	Marshal(v interface{}) ([]byte, error)
+}
+
+// JSONCodec 使用 encoding/json 序列化对象
+type JSONCodec struct{}
+
+// ContentType 实现 Codec 接口
+func (JSONCodec) ContentType() string {
+	return "application/json"
+}
+
+// Marshal 实现 Codec 接口
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// ServiceOption 用于定制 Service 的行为
+type ServiceOption func(*Service)
+
+// WithCodec 设置 SendObjectInTx 使用的序列化方式，默认为 JSONCodec
+func WithCodec(codec Codec) ServiceOption {
+	return func(s *Service) {
+		s.codec = codec
+	}
+}
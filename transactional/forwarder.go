@@ -2,42 +2,220 @@ package transactional
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/wangyingjie930/nexus-pkg/logger"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Forwarder 是一个后台任务，负责周期性地转发待发送的消息
 type Forwarder struct {
-	service  *Service
-	ticker   *time.Ticker
+	service *Service
+
+	// mu 保护 interval，它可以通过 SetInterval 或自适应模式在运行中修改
+	mu       sync.Mutex
 	interval time.Duration
+	// 自适应模式下间隔的上下限，maxInterval 为 0 时关闭自适应，见 WithAdaptiveInterval
+	minInterval time.Duration
+	maxInterval time.Duration
+	// jitter 是每次间隔的随机抖动比例，取值 [0, 1)，为 0 时使用固定间隔
+	jitter float64
+
+	// paused 为 true 时跳过转发周期，见 Pause/Resume
+	paused atomic.Bool
+	// wake 用于在 Resume 之后立即触发一次转发，而不必等待完整的间隔
+	wake chan struct{}
+	// reset 用于在 SetInterval 之后按新的间隔重置定时器
+	reset chan struct{}
+}
+
+// ForwarderOption 用于定制 Forwarder 的行为
+type ForwarderOption func(*Forwarder)
+
+// WithJitter 为每次轮询间隔加入随机抖动: 实际间隔在 interval*(1-fraction) 到 interval*(1+fraction) 之间均匀分布。
+// 同时部署的多个副本会因此错开 FindPendingMessages 查询，避免数据库负载尖峰。
+// fraction 会被限制在 [0, 1) 内，为 0 时保持固定间隔。
+func WithJitter(fraction float64) ForwarderOption {
+	return func(f *Forwarder) {
+		f.jitter = min(max(fraction, 0), 0.99)
+	}
+}
+
+// WithAdaptiveInterval 开启自适应轮询间隔: 上一轮取满一整批消息(存在积压)时间隔减半，但不低于 minInterval；
+// 上一轮没有任何消息时间隔加倍，但不超过 maxInterval。积压时降低转发延迟，空闲时减少数据库查询。
+// minInterval <= 0 或 maxInterval < minInterval 时该选项不生效。
+func WithAdaptiveInterval(minInterval, maxInterval time.Duration) ForwarderOption {
+	return func(f *Forwarder) {
+		if minInterval <= 0 || maxInterval < minInterval {
+			return
+		}
+		f.minInterval = minInterval
+		f.maxInterval = maxInterval
+	}
 }
 
 // NewForwarder 创建一个新的消息转发器
-func NewForwarder(service *Service, interval time.Duration) *Forwarder {
-	return &Forwarder{
+func NewForwarder(service *Service, interval time.Duration, opts ...ForwarderOption) *Forwarder {
+	f := &Forwarder{
 		service:  service,
 		interval: interval,
+		wake:     make(chan struct{}, 1),
+		reset:    make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(f)
 	}
+	if f.adaptive() {
+		f.interval = min(max(f.interval, f.minInterval), f.maxInterval)
+	}
+	return f
 }
 
 // Start 启动转发器。它会阻塞直到上下文被取消。
+// 每个转发周期结束后重新计算下一次间隔(见 WithJitter)，Resume 会立即触发一次转发。
 func (f *Forwarder) Start(ctx context.Context) error {
 	log := logger.Ctx(ctx)
-	log.Info().Dur("interval", f.interval).Msg("starting transactional message forwarder")
-	f.ticker = time.NewTicker(f.interval)
-	defer f.ticker.Stop()
+	log.Info().Dur("interval", f.Interval()).Float64("jitter", f.jitter).Bool("adaptive", f.adaptive()).Msg("starting transactional message forwarder")
+	timer := time.NewTimer(f.nextInterval())
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			log.Info().Msg("stopping transactional message forwarder")
 			return nil
-		case <-f.ticker.C:
-			log.Debug().Msg("forwarder tick: checking for pending messages")
-			if err := f.service.ForwardPendingMessages(ctx); err != nil {
-				log.Error().Err(err).Msg("error during message forwarding cycle")
+		case <-f.reset:
+			timer.Reset(f.nextInterval())
+		case <-f.wake:
+			f.forward(ctx)
+			timer.Reset(f.nextInterval())
+		case <-timer.C:
+			f.forward(ctx)
+			timer.Reset(f.nextInterval())
+		}
+	}
+}
+
+// SetInterval 修改轮询间隔，正在等待的下一次转发会立即按新的间隔重新计时。
+// 自适应模式下 d 会被限制在 WithAdaptiveInterval 的上下限之内，并作为之后自适应调整的起点。
+func (f *Forwarder) SetInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	f.mu.Lock()
+	if f.adaptive() {
+		d = min(max(d, f.minInterval), f.maxInterval)
+	}
+	f.interval = d
+	f.mu.Unlock()
+	logger.Logger.Info().Dur("interval", d).Msg("transactional message forwarder interval changed")
+	select {
+	case f.reset <- struct{}{}:
+	default:
+	}
+}
+
+// Interval 返回当前的轮询间隔(不含抖动)
+func (f *Forwarder) Interval() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.interval
+}
+
+// adaptive 返回是否开启了自适应模式
+func (f *Forwarder) adaptive() bool {
+	return f.maxInterval > 0
+}
+
+// adapt 根据上一轮找到的消息数调整自适应模式下的轮询间隔
+func (f *Forwarder) adapt(found int) {
+	if !f.adaptive() {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case found >= forwardBatchSize:
+		f.interval = max(f.interval/2, f.minInterval)
+	case found == 0:
+		f.interval = min(f.interval*2, f.maxInterval)
+	}
+}
+
+// Pause 暂停转发: 后续的转发周期会被跳过，正在进行的周期不受影响。
+// 适用于 Kafka 故障等场景，避免白白消耗消息的重试次数。
+func (f *Forwarder) Pause() {
+	if f.paused.CompareAndSwap(false, true) {
+		logger.Logger.Warn().Msg("⚠️ Transactional message forwarder paused")
+	}
+}
+
+// Resume 恢复转发，并立即触发一次转发周期
+func (f *Forwarder) Resume() {
+	if !f.paused.CompareAndSwap(true, false) {
+		return
+	}
+	logger.Logger.Info().Msg("✅ Transactional message forwarder resumed")
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Paused 返回转发器当前是否处于暂停状态
+func (f *Forwarder) Paused() bool {
+	return f.paused.Load()
+}
+
+// Handler 返回用于运维的 HTTP 端点，挂载到管理端口即可在线暂停/恢复转发:
+// GET 返回当前状态；POST ?action=pause 或 ?action=resume 切换状态。
+//
+//	mux.Handle("/admin/outbox/forwarder", forwarder.Handler())
+func (f *Forwarder) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			switch r.URL.Query().Get("action") {
+			case "pause":
+				f.Pause()
+			case "resume":
+				f.Resume()
+			default:
+				http.Error(w, "action must be pause or resume", http.StatusBadRequest)
+				return
 			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
 		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]bool{"paused": f.Paused()})
+	})
+}
+
+// nextInterval 返回加入随机抖动后的下一次轮询间隔
+func (f *Forwarder) nextInterval() time.Duration {
+	factor := 1 + f.jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(f.Interval()) * factor)
+}
+
+// forward 执行一次转发周期
+func (f *Forwarder) forward(ctx context.Context) {
+	log := logger.Ctx(ctx)
+	if f.paused.Load() {
+		log.Debug().Msg("forwarder tick skipped: forwarder is paused")
+		return
+	}
+	log.Debug().Msg("forwarder tick: checking for pending messages")
+	found, err := f.service.forwardPendingMessages(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("error during message forwarding cycle")
+		return
 	}
+	f.adapt(found)
 }
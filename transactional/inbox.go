@@ -0,0 +1,118 @@
+package transactional
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+	"github.com/wangyingjie930/nexus-pkg/mq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InboxMessage 对应数据库中的收件箱表 (inbox_messages)，记录每个消费者已经处理过的消息 ID。
+// 建议表结构包含: id (BIGINT, PK), consumer (VARCHAR), message_id (VARCHAR), topic (VARCHAR), processed_at (DATETIME)，
+// 并在 (consumer, message_id) 上建立唯一索引。
+type InboxMessage struct {
+	ID          int64     `gorm:"primaryKey"`
+	Consumer    string    `gorm:"type:varchar(128);not null;uniqueIndex:idx_inbox_consumer_message"`
+	MessageID   string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_inbox_consumer_message"`
+	Topic       string    `gorm:"type:varchar(255)"`
+	ProcessedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (InboxMessage) TableName() string {
+	return "inbox_messages"
+}
+
+// InboxStore 定义了对收件箱表的操作接口
+type InboxStore interface {
+	// MarkProcessed 在给定的数据库事务中记录 consumer 已处理 messageID。
+	// 该消息此前已被记录过时返回 false，调用方应跳过处理。
+	MarkProcessed(ctx context.Context, tx *gorm.DB, consumer, messageID, topic string) (bool, error)
+}
+
+// gormInboxStore 是 InboxStore 接口的 GORM 实现
+type gormInboxStore struct{}
+
+// NewGormInboxStore 创建一个新的 GORM InboxStore 实例，写入总是使用调用方传入的事务。
+// 它不会建表，收件箱表由 AutoMigrate 与事务消息表一起创建。
+func NewGormInboxStore() InboxStore {
+	return gormInboxStore{}
+}
+
+func (gormInboxStore) MarkProcessed(ctx context.Context, tx *gorm.DB, consumer, messageID, topic string) (bool, error) {
+	// 依赖唯一索引: 冲突时不插入，RowsAffected 为 0 即说明消息已经处理过
+	result := tx.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&InboxMessage{Consumer: consumer, MessageID: messageID, Topic: topic})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// InboxHandler 在业务事务中处理一条消息，业务写入应使用传入的 tx
+type InboxHandler func(ctx context.Context, tx *gorm.DB, msg kafka.Message) error
+
+// Inbox 实现收件箱模式: 在同一个数据库事务中记录消息 ID 并执行业务写入，
+// 已记录过的消息会被跳过。与 outbox 配合即可仅依赖本包构建端到端 exactly-once 的处理链路。
+type Inbox struct {
+	db       *gorm.DB
+	store    InboxStore
+	consumer string
+}
+
+// NewInbox 创建一个收件箱。consumer 用于区分不同的消费者(通常为消费组名)，
+// 同一条消息可以被不同的 consumer 各处理一次。
+func NewInbox(db *gorm.DB, store InboxStore, consumer string) *Inbox {
+	return &Inbox{
+		db:       db,
+		store:    store,
+		consumer: consumer,
+	}
+}
+
+// Process 在一个数据库事务中记录消息并调用 handler。
+// 消息已被处理过时直接返回 nil；handler 返回错误时事务回滚，消息在重新投递时可以再次处理。
+func (i *Inbox) Process(ctx context.Context, msg kafka.Message, handler InboxHandler) error {
+	id := inboxMessageID(msg)
+	skipped := false
+	err := i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fresh, err := i.store.MarkProcessed(ctx, tx, i.consumer, id, msg.Topic)
+		if err != nil {
+			return fmt.Errorf("failed to record inbox message %s: %w", id, err)
+		}
+		if !fresh {
+			skipped = true
+			return nil
+		}
+		return handler(ctx, tx, msg)
+	})
+	if err != nil {
+		return err
+	}
+	if skipped {
+		logger.Ctx(ctx).Info().Str("messageId", id).Str("topic", msg.Topic).Str("consumer", i.consumer).Msg("duplicate message skipped by inbox")
+	}
+	return nil
+}
+
+// Wrap 将 InboxHandler 适配为 mq.MessageHandler，便于直接交给消费者使用
+func (i *Inbox) Wrap(handler InboxHandler) mq.MessageHandler {
+	return func(ctx context.Context, msg kafka.Message) error {
+		return i.Process(ctx, msg, handler)
+	}
+}
+
+// inboxMessageID 优先使用 message-id 消息头(outbox 转发时写入)；
+// 没有该消息头时退化为 topic/partition/offset，只能识别同一条 Kafka 记录的重复投递。
+func inboxMessageID(msg kafka.Message) string {
+	if id, ok := mq.GetHeader(msg.Headers, mq.HeaderMessageID); ok && id != "" {
+		return id
+	}
+	return msg.Topic + "/" + strconv.Itoa(msg.Partition) + "/" + strconv.FormatInt(msg.Offset, 10)
+}
@@ -17,17 +17,20 @@ const (
 )
 
 // Message 对应数据库中的事务消息表 (transactional_messages)
-// 建议表结构包含: id (BIGINT, PK), topic (VARCHAR), `key` (VARCHAR), payload (TEXT/BLOB),
-// status (VARCHAR), retry_count (INT), created_at (DATETIME), updated_at (DATETIME)
+// 建议表结构包含: id (BIGINT, PK), business_id (VARCHAR), topic (VARCHAR), `key` (VARCHAR), payload (TEXT/BLOB),
+// headers (TEXT), content_type (VARCHAR), status (VARCHAR), retry_count (INT), created_at (DATETIME), updated_at (DATETIME)
 type Message struct {
-	ID         int64     `gorm:"primaryKey"`
-	Topic      string    `gorm:"type:varchar(255);not null"`
-	Key        string    `gorm:"type:varchar(255)"`
-	Payload    []byte    `gorm:"type:blob;not null"`
-	Status     Status    `gorm:"type:varchar(20);not null;index"`
-	RetryCount int       `gorm:"not null;default:0"`
-	CreatedAt  time.Time `gorm:"autoCreateTime"`
-	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+	ID          int64             `gorm:"primaryKey"`
+	BusinessID  string            `gorm:"type:varchar(64);index"` // 全局唯一、按时间有序的业务 ID，用于下游去重
+	Topic       string            `gorm:"type:varchar(255);not null"`
+	Key         string            `gorm:"type:varchar(255)"`
+	Payload     []byte            `gorm:"type:blob;not null"`
+	Headers     map[string]string `gorm:"type:text;serializer:json"` // 转发时写入 Kafka 消息头
+	ContentType string            `gorm:"type:varchar(100)"`         // 消息体的内容类型，转发时写入 content-type 消息头，为空表示未知
+	Status      Status            `gorm:"type:varchar(20);not null;index"`
+	RetryCount  int               `gorm:"not null;default:0"`
+	CreatedAt   time.Time         `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time         `gorm:"autoUpdateTime"`
 }
 
 func (Message) TableName() string {
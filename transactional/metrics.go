@@ -0,0 +1,42 @@
+package transactional
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	messagesForwarded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "outbox",
+		Name:      "messages_forwarded_total",
+		Help:      "Number of transactional messages forwarded to Kafka, labeled by topic and result.",
+	}, []string{"topic", "result"})
+
+	forwardDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "outbox",
+		Name:      "forward_duration_seconds",
+		Help:      "Duration of a full forwarding cycle.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	pendingMessages = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "outbox",
+		Name:      "pending_messages",
+		Help:      "Number of pending transactional messages observed by the last forwarding cycle.",
+	})
+)
+
+// RegisterMetrics 将事务消息相关的指标注册到调用方的 Registerer 中。
+// 重复注册是安全的，已注册的指标会被忽略。
+func RegisterMetrics(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{messagesForwarded, forwardDuration, pendingMessages} {
+		if err := reg.Register(c); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if !errors.As(err, &are) {
+				return err
+			}
+		}
+	}
+	return nil
+}
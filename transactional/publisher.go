@@ -0,0 +1,72 @@
+package transactional
+
+import (
+	"context"
+	"sync"
+)
+
+// Event 描述一条需要可靠发布的领域事件
+type Event struct {
+	Topic   string
+	Key     string
+	Payload []byte
+	Headers map[string]string
+	// ContentType 为 Payload 的内容类型，可选
+	ContentType string
+}
+
+// EventPublisher 是业务代码依赖的事件发布抽象，与具体的数据库访问方式无关。
+// 业务方正在使用的数据库事务通过 ctx 传递(GORM 使用 ContextWithTx，database/sql 使用 ContextWithSQLTx)，
+// 事件会与业务写入在同一事务中落库；ctx 中没有事务时使用 Store 默认的数据库连接。
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+var (
+	_ EventPublisher = (*Service)(nil)
+	_ EventPublisher = (*MemoryPublisher)(nil)
+	_ EventPublisher = NopPublisher{}
+)
+
+// NopPublisher 丢弃所有事件，适用于不关心事件发布的测试
+type NopPublisher struct{}
+
+// Publish 实现 EventPublisher 接口
+func (NopPublisher) Publish(context.Context, Event) error {
+	return nil
+}
+
+// MemoryPublisher 将事件记录在内存中，便于在不依赖数据库的情况下断言发布的事件
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewMemoryPublisher 创建一个新的内存事件发布器
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish 实现 EventPublisher 接口
+func (p *MemoryPublisher) Publish(_ context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events 返回已记录事件的副本
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	events := make([]Event, len(p.events))
+	copy(events, p.events)
+	return events
+}
+
+// Reset 清空已记录的事件
+func (p *MemoryPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = nil
+}
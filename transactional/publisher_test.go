@@ -0,0 +1,219 @@
+package transactional
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// memoryStore 是测试用的内存 Store 实现
+type memoryStore struct {
+	mu       sync.Mutex
+	nextID   int64
+	messages []*Message
+	// txs 记录每次写入时 ctx 携带的业务事务(*gorm.DB 或 Tx)，没有事务时为 nil
+	txs []any
+}
+
+func (s *memoryStore) CreateInTx(ctx context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tx any
+	if gormTx, ok := txFromContext(ctx); ok {
+		tx = gormTx
+	} else if sqlTx, ok := sqlTxFromContext(ctx); ok {
+		tx = sqlTx
+	}
+	s.txs = append(s.txs, tx)
+	s.nextID++
+	msg.ID = s.nextID
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func (s *memoryStore) FindPendingMessages(_ context.Context, limit int) ([]*Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []*Message
+	for _, msg := range s.messages {
+		if msg.Status == StatusPending && len(pending) < limit {
+			copied := *msg
+			pending = append(pending, &copied)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memoryStore) UpdateStatus(_ context.Context, id int64, status Status, newRetryCount int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, msg := range s.messages {
+		if msg.ID == id {
+			msg.Status = status
+			msg.RetryCount = newRetryCount
+			return nil
+		}
+	}
+	return fmt.Errorf("message %d not found", id)
+}
+
+func (s *memoryStore) all() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := make([]Message, len(s.messages))
+	for i, msg := range s.messages {
+		messages[i] = *msg
+	}
+	return messages
+}
+
+// placeOrder 模拟只依赖 EventPublisher 的业务代码
+func placeOrder(ctx context.Context, publisher EventPublisher, orderID string) error {
+	return publisher.Publish(ctx, Event{Topic: "orders.created", Key: orderID, Payload: []byte(`{"id":"` + orderID + `"}`)})
+}
+
+func TestMemoryPublisherRecordsEvents(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	ctx := context.Background()
+	if err := placeOrder(ctx, publisher, "o-1"); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	if err := placeOrder(ctx, publisher, "o-2"); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+
+	events := publisher.Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Key != "o-1" || events[1].Key != "o-2" || events[0].Topic != "orders.created" {
+		t.Errorf("events = %+v, want o-1 and o-2 on orders.created in order", events)
+	}
+
+	// Events 返回副本，修改它不影响已记录的事件
+	events[0].Key = "changed"
+	if got := publisher.Events()[0].Key; got != "o-1" {
+		t.Errorf("recorded key = %q after modifying the copy, want o-1", got)
+	}
+
+	publisher.Reset()
+	if got := len(publisher.Events()); got != 0 {
+		t.Errorf("got %d events after Reset, want 0", got)
+	}
+}
+
+func TestMemoryPublisherConcurrentPublish(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = placeOrder(context.Background(), publisher, fmt.Sprintf("o-%d", i))
+		}()
+	}
+	wg.Wait()
+	if got := len(publisher.Events()); got != 50 {
+		t.Errorf("got %d events, want 50", got)
+	}
+}
+
+func TestNopPublisherDiscardsEvents(t *testing.T) {
+	if err := placeOrder(context.Background(), NopPublisher{}, "o-1"); err != nil {
+		t.Errorf("Publish returned error: %v", err)
+	}
+}
+
+func TestServicePublishStoresPendingMessage(t *testing.T) {
+	store := &memoryStore{}
+	service := NewService(store, nil)
+
+	err := service.Publish(context.Background(), Event{
+		Topic:       "orders.created",
+		Key:         "o-1",
+		Payload:     []byte(`{"id":"o-1"}`),
+		Headers:     map[string]string{"tenant": "acme"},
+		ContentType: "application/json",
+	})
+	if err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+
+	messages := store.all()
+	if len(messages) != 1 {
+		t.Fatalf("got %d stored messages, want 1", len(messages))
+	}
+	msg := messages[0]
+	if msg.Topic != "orders.created" || msg.Key != "o-1" || string(msg.Payload) != `{"id":"o-1"}` {
+		t.Errorf("stored message = %+v, want the published event", msg)
+	}
+	if msg.Status != StatusPending {
+		t.Errorf("Status = %v, want %v", msg.Status, StatusPending)
+	}
+	if msg.BusinessID == "" {
+		t.Error("BusinessID is empty, want a generated ID")
+	}
+	if msg.Headers["tenant"] != "acme" || msg.ContentType != "application/json" {
+		t.Errorf("headers = %v, content type = %q, want tenant header and application/json", msg.Headers, msg.ContentType)
+	}
+}
+
+// fakeSQLTx 是测试用的 Tx 实现，只用于确认事务被传递到 Store
+type fakeSQLTx struct{}
+
+func (*fakeSQLTx) ExecContext(context.Context, string, ...any) (sql.Result, error) { return nil, nil }
+func (*fakeSQLTx) QueryRowContext(context.Context, string, ...any) *sql.Row        { return nil }
+
+func TestServicePublishUsesTransactionFromContext(t *testing.T) {
+	store := &memoryStore{}
+	service := NewService(store, nil)
+	event := Event{Topic: "orders.created", Key: "o-1", Payload: []byte(`{"id":"o-1"}`)}
+
+	gormTx := &gorm.DB{}
+	sqlTx := &fakeSQLTx{}
+
+	// 只依赖 EventPublisher 的业务代码通过 ctx 传入事务，不需要关心事务来自 GORM 还是 database/sql
+	var publisher EventPublisher = service
+	if err := publisher.Publish(ContextWithSQLTx(context.Background(), sqlTx), event); err != nil {
+		t.Fatalf("Publish with a database/sql tx returned error: %v", err)
+	}
+	if err := service.PublishInSQLTx(context.Background(), sqlTx, event); err != nil {
+		t.Fatalf("PublishInSQLTx returned error: %v", err)
+	}
+	if err := service.PublishInTx(context.Background(), gormTx, event); err != nil {
+		t.Fatalf("PublishInTx returned error: %v", err)
+	}
+	if err := service.PublishInTx(context.Background(), nil, event); err != nil {
+		t.Fatalf("PublishInTx without a tx returned error: %v", err)
+	}
+
+	want := []any{sqlTx, sqlTx, gormTx, nil}
+	if len(store.txs) != len(want) {
+		t.Fatalf("store saw %d writes, want %d", len(store.txs), len(want))
+	}
+	for i := range want {
+		if store.txs[i] != want[i] {
+			t.Errorf("write %d used tx %v, want %v", i, store.txs[i], want[i])
+		}
+	}
+}
+
+func TestServiceSendObjectInSQLTx(t *testing.T) {
+	store := &memoryStore{}
+	service := NewService(store, nil)
+	sqlTx := &fakeSQLTx{}
+
+	if err := service.SendObjectInSQLTx(context.Background(), sqlTx, "orders.created", "o-1", map[string]string{"id": "o-1"}); err != nil {
+		t.Fatalf("SendObjectInSQLTx returned error: %v", err)
+	}
+	if len(store.txs) != 1 || store.txs[0] != sqlTx {
+		t.Errorf("store saw txs %v, want the database/sql tx", store.txs)
+	}
+	if msg := store.all()[0]; string(msg.Payload) != `{"id":"o-1"}` || msg.ContentType == "" {
+		t.Errorf("stored message = %+v, want the JSON-encoded object with a content type", msg)
+	}
+}
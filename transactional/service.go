@@ -2,54 +2,224 @@ package transactional
 
 import (
 	"context"
+	"fmt"
 	"github.com/segmentio/kafka-go"
+	"github.com/wangyingjie930/nexus-pkg/idgen"
 	"github.com/wangyingjie930/nexus-pkg/logger"
 	"github.com/wangyingjie930/nexus-pkg/mq"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"sync"
+	"time"
 )
 
+// forwardBatchSize 是每个转发周期最多处理的消息数
+const forwardBatchSize = 100
+
 // Service 封装了事务性消息的核心逻辑
 type Service struct {
 	store  Store
-	writer *kafka.Writer // 复用 Kafka 生产者
+	writer mq.MessageWriter // 复用 Kafka 生产者，通常为 *kafka.Writer
+
+	// codec 是 SendObjectInTx 使用的序列化方式
+	codec Codec
+
+	// forwarding 保证同一进程内同时只有一个转发周期在运行
+	forwarding sync.Mutex
 }
 
-// NewService 创建一个新的事务性消息服务
-func NewService(store Store, writer *kafka.Writer) *Service {
-	return &Service{
+// NewService 创建一个新的事务性消息服务。
+// writer 通常为 *kafka.Writer，测试中可以注入记录消息或模拟发送失败的 mq.MessageWriter 实现。
+func NewService(store Store, writer mq.MessageWriter, opts ...ServiceOption) *Service {
+	s := &Service{
 		store:  store,
 		writer: writer,
+		codec:  JSONCodec{},
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // SendInTx 在业务事务中保存待发送的消息。
 // 这是给业务代码调用的核心方法。
+// topic 通过 mq.RegisterSchema 注册了 schema 时，消息体在落库前被校验，不符合时返回 mq.ErrSchemaMismatch。
+// ctx 中的 trace context 会随消息一起保存，转发消息的 span 通过 span link 关联到当前的业务 span。
 func (s *Service) SendInTx(ctx context.Context, topic, key string, payload []byte) error {
+	headers, err := outboxHeaders(ctx, topic, payload, nil)
+	if err != nil {
+		return err
+	}
 	msg := &Message{
-		Topic:   topic,
-		Key:     key,
-		Payload: payload,
-		Status:  StatusPending,
+		BusinessID: idgen.New(),
+		Topic:      topic,
+		Key:        key,
+		Payload:    payload,
+		Headers:    headers,
+		Status:     StatusPending,
 	}
 
 	// 将消息的创建操作包含在业务方的DB事务中
 	return s.store.CreateInTx(ctx, msg)
 }
 
+// SendInSQLTx 与 SendInTx 相同，但在 database/sql(或 sqlx)的事务 tx 中保存消息，Store 需要由 NewSQLStore 创建
+func (s *Service) SendInSQLTx(ctx context.Context, tx Tx, topic, key string, payload []byte) error {
+	return s.SendInTx(ContextWithSQLTx(ctx, tx), topic, key, payload)
+}
+
+// SendObjectInTx 使用配置的 Codec 序列化 obj，并在 tx 所代表的 GORM 事务中保存待发送的消息。
+// 消息会记录 Codec 的内容类型，转发时写入 content-type 消息头，消费端据此反序列化。
+// tx 为 nil 时使用 Store 默认的数据库连接。
+func (s *Service) SendObjectInTx(ctx context.Context, tx *gorm.DB, topic, key string, obj interface{}) error {
+	if tx != nil {
+		ctx = ContextWithTx(ctx, tx)
+	}
+	return s.sendObject(ctx, topic, key, obj)
+}
+
+// SendObjectInSQLTx 与 SendObjectInTx 相同，但在 database/sql(或 sqlx)的事务 tx 中保存消息，Store 需要由 NewSQLStore 创建
+func (s *Service) SendObjectInSQLTx(ctx context.Context, tx Tx, topic, key string, obj interface{}) error {
+	return s.sendObject(ContextWithSQLTx(ctx, tx), topic, key, obj)
+}
+
+// sendObject 序列化 obj 并在 ctx 携带的业务事务中保存待发送的消息
+func (s *Service) sendObject(ctx context.Context, topic, key string, obj interface{}) error {
+	payload, err := s.codec.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload for topic %s: %w", topic, err)
+	}
+	headers, err := outboxHeaders(ctx, topic, payload, nil)
+	if err != nil {
+		return err
+	}
+	msg := &Message{
+		BusinessID:  idgen.New(),
+		Topic:       topic,
+		Key:         key,
+		Payload:     payload,
+		Headers:     headers,
+		ContentType: s.codec.ContentType(),
+		Status:      StatusPending,
+	}
+	return s.store.CreateInTx(ctx, msg)
+}
+
+// PublishInTx 将事件与 tx 所代表的 GORM 事务一起写入事务消息表，tx 为 nil 时使用 Store 默认的数据库连接
+func (s *Service) PublishInTx(ctx context.Context, tx *gorm.DB, event Event) error {
+	if tx != nil {
+		ctx = ContextWithTx(ctx, tx)
+	}
+	return s.Publish(ctx, event)
+}
+
+// PublishInSQLTx 与 PublishInTx 相同，但使用 database/sql(或 sqlx)的事务 tx，Store 需要由 NewSQLStore 创建
+func (s *Service) PublishInSQLTx(ctx context.Context, tx Tx, event Event) error {
+	return s.Publish(ContextWithSQLTx(ctx, tx), event)
+}
+
+// Publish 实现 EventPublisher 接口，将事件与 ctx 携带的业务事务一起写入事务消息表。
+func (s *Service) Publish(ctx context.Context, event Event) error {
+	headers, err := outboxHeaders(ctx, event.Topic, event.Payload, event.Headers)
+	if err != nil {
+		return err
+	}
+	msg := &Message{
+		BusinessID:  idgen.New(),
+		Topic:       event.Topic,
+		Key:         event.Key,
+		Payload:     event.Payload,
+		Headers:     headers,
+		ContentType: event.ContentType,
+		Status:      StatusPending,
+	}
+	return s.store.CreateInTx(ctx, msg)
+}
+
+// outboxHeaders 返回事务消息要保存的消息头: 校验 schema 并补充 schema-version，
+// 同时记录业务事务当前的 trace context，转发时用于把转发 span 链接回写入消息的业务操作
+func outboxHeaders(ctx context.Context, topic string, payload []byte, headers map[string]string) (map[string]string, error) {
+	headers, err := schemaHeaders(topic, payload, headers)
+	if err != nil {
+		return nil, err
+	}
+	return stampOriginTrace(ctx, headers), nil
+}
+
+// schemaHeaders 按 topic 注册的 schema 校验消息体，并返回补充了 schema-version 的消息头副本。
+// 已经显式设置了 schema-version 的消息头保持不变。
+func schemaHeaders(topic string, payload []byte, headers map[string]string) (map[string]string, error) {
+	version, err := mq.CheckSchema(topic, payload)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := headers[mq.HeaderSchemaVersion]; version == "" || ok {
+		return headers, nil
+	}
+	stamped := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		stamped[k] = v
+	}
+	stamped[mq.HeaderSchemaVersion] = version
+	return stamped, nil
+}
+
 // ForwardPendingMessages 查找并转发待处理的消息
 // 这个方法应该被一个后台任务周期性地调用
+//
+// 同一进程内的并发调用不会重叠: 如果已有一个转发周期在运行，新的调用会直接跳过并返回 nil，
+// 避免同一批 PENDING 消息被重复发送。该保护仅限于进程内，多副本部署时仍需要在数据库层面认领消息。
+//
+// 每个转发周期对应一个 forward_cycle span，记录本轮找到、发送成功和失败的消息数，
+// 每条消息的 forward_message span 都是它的子 span。
 func (s *Service) ForwardPendingMessages(ctx context.Context) error {
+	_, err := s.forwardPendingMessages(ctx)
+	return err
+}
+
+// forwardPendingMessages 执行一个转发周期，并返回本轮找到的消息数，
+// Forwarder 的自适应模式据此判断是否存在积压
+func (s *Service) forwardPendingMessages(ctx context.Context) (found int, err error) {
+	if !s.forwarding.TryLock() {
+		logger.Ctx(ctx).Debug().Msg("forwarding cycle already in progress, skipping")
+		return 0, nil
+	}
+	defer s.forwarding.Unlock()
+
+	tracer := otel.Tracer("transactional-forwarder")
+	ctx, cycleSpan := tracer.Start(ctx, "forward_cycle")
+	defer cycleSpan.End()
 	log := logger.Ctx(ctx)
 
+	start := time.Now()
+	var sent, failed int
+	defer func() {
+		elapsed := time.Since(start)
+		forwardDuration.Observe(elapsed.Seconds())
+		cycleSpan.SetAttributes(
+			attribute.Int("outbox.messages_sent", sent),
+			attribute.Int("outbox.messages_failed", failed),
+			attribute.Int64("outbox.cycle_duration_ms", elapsed.Milliseconds()),
+		)
+	}()
+
 	// 1. 查找待发送的消息
-	messages, err := s.store.FindPendingMessages(ctx, 100) // 每次最多处理100条
+	messages, err := s.store.FindPendingMessages(ctx, forwardBatchSize)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to find pending messages")
-		return err
+		cycleSpan.RecordError(err)
+		cycleSpan.SetStatus(codes.Error, "failed to find pending messages")
+		return 0, err
 	}
+	cycleSpan.SetAttributes(attribute.Int("outbox.messages_found", len(messages)))
+	s.reportPending(ctx, len(messages))
 
 	if len(messages) == 0 {
-		return nil // 没有待处理消息
+		return 0, nil // 没有待处理消息
 	}
 
 	log.Info().Int("count", len(messages)).Msg("found pending transactional messages to forward")
@@ -62,15 +232,31 @@ func (s *Service) ForwardPendingMessages(ctx context.Context) error {
 			Key:   []byte(msg.Key),
 			Value: msg.Payload,
 		}
+		for k, v := range msg.Headers {
+			kafkaMsg.Headers = append(kafkaMsg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+		if msg.BusinessID != "" {
+			kafkaMsg.Headers = append(kafkaMsg.Headers, kafka.Header{Key: mq.HeaderMessageID, Value: []byte(msg.BusinessID)})
+		}
+		if msg.ContentType != "" {
+			mq.SetHeader(&kafkaMsg, mq.HeaderContentType, msg.ContentType)
+		}
 
 		// 注入 OpenTelemetry trace context，实现全链路追踪
-		// 注意这里我们从后台任务的context中创建新的追踪信息
-		tracer := otel.Tracer("transactional-forwarder")
-		spanCtx, span := tracer.Start(ctx, "forward_message")
+		// 每条消息的 span 都是本轮转发周期 span 的子 span
+		// 转发发生在另一个 trace 中，通过 span link 关联写入消息时的业务 span
+		spanCtx, span := tracer.Start(ctx, "forward_message", trace.WithAttributes(
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.Int64("outbox.message_id", msg.ID),
+		), trace.WithLinks(originLinks(msg.Headers)...))
 		mq.InjectTraceContext(spanCtx, &kafkaMsg.Headers)
 
 		// 3. 发送消息
 		err := s.writer.WriteMessages(spanCtx, kafkaMsg)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to write message to kafka")
+		}
 		span.End()
 
 		// 4. 更新消息状态
@@ -79,11 +265,27 @@ func (s *Service) ForwardPendingMessages(ctx context.Context) error {
 			// 简单地增加重试次数，可以引入更复杂的重试策略（如指数退避）
 			// 当重试次数超过阈值时，可以标记为 FAILED
 			_ = s.store.UpdateStatus(ctx, msg.ID, StatusPending, msg.RetryCount+1)
+			messagesForwarded.WithLabelValues(msg.Topic, "error").Inc()
+			failed++
 		} else {
 			log.Info().Int64("msg_id", msg.ID).Str("topic", msg.Topic).Msg("successfully forwarded message")
 			_ = s.store.UpdateStatus(ctx, msg.ID, StatusSent, msg.RetryCount)
+			messagesForwarded.WithLabelValues(msg.Topic, "success").Inc()
+			sent++
 		}
 	}
 
-	return nil
+	return len(messages), nil
+}
+
+// reportPending 上报待发送消息的积压数量。
+// Store 实现了 PendingCounter 时上报精确总数，否则退化为本轮查询到的数量(受批量大小限制)。
+func (s *Service) reportPending(ctx context.Context, found int) {
+	if counter, ok := s.store.(PendingCounter); ok {
+		if count, err := counter.CountPendingMessages(ctx); err == nil {
+			pendingMessages.Set(float64(count))
+			return
+		}
+	}
+	pendingMessages.Set(float64(found))
 }
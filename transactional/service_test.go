@@ -0,0 +1,90 @@
+package transactional
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// blockingWriter 记录写入的消息，第一次写入会阻塞到 release 被关闭，以制造并发的转发周期
+type blockingWriter struct {
+	release chan struct{}
+	started chan struct{}
+	once    sync.Once
+
+	mu      sync.Mutex
+	written map[string]int
+}
+
+func (w *blockingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.once.Do(func() {
+		close(w.started)
+		<-w.release
+	})
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, msg := range msgs {
+		w.written[string(msg.Key)]++
+	}
+	return nil
+}
+
+func TestConcurrentForwardPendingMessagesSendsOnce(t *testing.T) {
+	store := &memoryStore{}
+	writer := &blockingWriter{release: make(chan struct{}), started: make(chan struct{}), written: map[string]int{}}
+	service := NewService(store, writer)
+
+	ctx := context.Background()
+	const messages = 10
+	for i := 0; i < messages; i++ {
+		if err := service.Publish(ctx, Event{Topic: "orders.created", Key: fmt.Sprintf("o-%d", i), Payload: []byte("{}")}); err != nil {
+			t.Fatalf("Publish returned error: %v", err)
+		}
+	}
+
+	// 第一个转发周期阻塞在写入上
+	first := make(chan error, 1)
+	go func() { first <- service.ForwardPendingMessages(ctx) }()
+	select {
+	case <-writer.started:
+	case <-time.After(time.Second):
+		t.Fatal("first forwarding cycle did not start")
+	}
+
+	// 与之并发的转发周期应直接跳过，而不是重复发送同一批消息
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := service.ForwardPendingMessages(ctx); err != nil {
+				t.Errorf("concurrent ForwardPendingMessages returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(writer.release)
+	if err := <-first; err != nil {
+		t.Fatalf("ForwardPendingMessages returned error: %v", err)
+	}
+
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if len(writer.written) != messages {
+		t.Errorf("%d distinct messages written, want %d", len(writer.written), messages)
+	}
+	for key, n := range writer.written {
+		if n != 1 {
+			t.Errorf("message %s written %d times, want 1", key, n)
+		}
+	}
+	for _, msg := range store.all() {
+		if msg.Status != StatusSent {
+			t.Errorf("message %d status = %v, want %v", msg.ID, msg.Status, StatusSent)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package transactional
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tx 是在业务事务中执行写入所需的最小接口，*sql.Tx、*sql.DB 以及 sqlx 的 *sqlx.Tx 都满足它。
+// 不使用 GORM 的业务通过 ContextWithSQLTx 把自己的事务交给 NewSQLStore 创建的 Store。
+type Tx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type sqlTxContextKey struct{}
+
+// ContextWithSQLTx 将业务方的 database/sql 事务放入 context，SQL Store 会在该事务中执行写入
+func ContextWithSQLTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, sqlTxContextKey{}, tx)
+}
+
+// sqlTxFromContext 从 context 中取出业务方的 database/sql 事务
+func sqlTxFromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := ctx.Value(sqlTxContextKey{}).(Tx)
+	return tx, ok && tx != nil
+}
+
+// Dialect 决定 SQL Store 生成的占位符与标识符引用方式
+type Dialect int
+
+const (
+	// DialectMySQL 使用 ? 占位符与反引号，DSN 需要开启 parseTime=true
+	DialectMySQL Dialect = iota
+	// DialectPostgres 使用 $n 占位符与双引号
+	DialectPostgres
+)
+
+// quote 引用列名，key 在 MySQL 中是保留字
+func (d Dialect) quote(ident string) string {
+	if d == DialectPostgres {
+		return `"` + ident + `"`
+	}
+	return "`" + ident + "`"
+}
+
+// rebind 将查询中的 ? 占位符替换为方言对应的形式
+func (d Dialect) rebind(query string) string {
+	if d != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sqlStore 是 Store 接口的 database/sql 实现
+type sqlStore struct {
+	db      *sql.DB
+	dialect Dialect
+	columns string
+}
+
+// NewSQLStore 创建一个基于 database/sql 的 Store，适用于使用 database/sql 或 sqlx 的业务。
+// 它不会建表，transactional_messages 表需要按 Message 的说明预先创建(或通过 GORM 调用 AutoMigrate)。
+func NewSQLStore(db *sql.DB, dialect Dialect) Store {
+	cols := []string{"id", "business_id", "topic", dialect.quote("key"), "payload", "headers", "content_type", "status", "retry_count", "created_at", "updated_at"}
+	return &sqlStore{
+		db:      db,
+		dialect: dialect,
+		columns: strings.Join(cols, ", "),
+	}
+}
+
+func (s *sqlStore) CreateInTx(ctx context.Context, msg *Message) error {
+	var tx Tx = s.db
+	if t, ok := sqlTxFromContext(ctx); ok {
+		tx = t
+	}
+
+	var headers sql.NullString
+	if msg.Headers != nil {
+		raw, err := json.Marshal(msg.Headers)
+		if err != nil {
+			return fmt.Errorf("failed to encode outbox headers: %w", err)
+		}
+		headers = sql.NullString{String: string(raw), Valid: true}
+	}
+	now := time.Now()
+	msg.CreatedAt, msg.UpdatedAt = now, now
+
+	query := "INSERT INTO " + Message{}.TableName() + " (business_id, topic, " + s.dialect.quote("key") +
+		", payload, headers, content_type, status, retry_count, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	args := []any{msg.BusinessID, msg.Topic, msg.Key, msg.Payload, headers, msg.ContentType, msg.Status, msg.RetryCount, now, now}
+
+	// Postgres 不支持 LastInsertId，通过 RETURNING 取回主键
+	if s.dialect == DialectPostgres {
+		return tx.QueryRowContext(ctx, s.dialect.rebind(query+" RETURNING id"), args...).Scan(&msg.ID)
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		msg.ID = id
+	}
+	return nil
+}
+
+func (s *sqlStore) FindPendingMessages(ctx context.Context, limit int) ([]*Message, error) {
+	query := s.dialect.rebind("SELECT " + s.columns + " FROM " + Message{}.TableName() +
+		" WHERE status = ? AND updated_at < ? ORDER BY id ASC LIMIT ?")
+	rows, err := s.db.QueryContext(ctx, query, StatusPending, time.Now().Add(-1*time.Minute), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		msg := &Message{}
+		var key, headers, contentType sql.NullString
+		if err := rows.Scan(&msg.ID, &msg.BusinessID, &msg.Topic, &key, &msg.Payload, &headers, &contentType,
+			&msg.Status, &msg.RetryCount, &msg.CreatedAt, &msg.UpdatedAt); err != nil {
+			return nil, err
+		}
+		msg.Key, msg.ContentType = key.String, contentType.String
+		if headers.Valid && headers.String != "" {
+			if err := json.Unmarshal([]byte(headers.String), &msg.Headers); err != nil {
+				return nil, fmt.Errorf("failed to decode headers of outbox message %d: %w", msg.ID, err)
+			}
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+func (s *sqlStore) UpdateStatus(ctx context.Context, id int64, status Status, newRetryCount int) error {
+	query := s.dialect.rebind("UPDATE " + Message{}.TableName() + " SET status = ?, retry_count = ?, updated_at = ? WHERE id = ?")
+	_, err := s.db.ExecContext(ctx, query, status, newRetryCount, time.Now(), id)
+	return err
+}
+
+func (s *sqlStore) CountPendingMessages(ctx context.Context) (int64, error) {
+	var count int64
+	query := s.dialect.rebind("SELECT COUNT(*) FROM " + Message{}.TableName() + " WHERE status = ?")
+	err := s.db.QueryRowContext(ctx, query, StatusPending).Scan(&count)
+	return count, err
+}
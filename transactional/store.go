@@ -16,25 +16,49 @@ type Store interface {
 	UpdateStatus(ctx context.Context, id int64, status Status, newRetryCount int) error
 }
 
+// PendingCounter 是 Store 的可选扩展，用于统计待发送消息的总数以上报积压指标
+type PendingCounter interface {
+	CountPendingMessages(ctx context.Context) (int64, error)
+}
+
+type txContextKey struct{}
+
+// ContextWithTx 将业务方的 GORM 事务放入 context，GORM Store 会在该事务中执行写入。
+// 使用 database/sql 的业务请配合 NewSQLStore 使用 ContextWithSQLTx。
+func ContextWithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// txFromContext 从 context 中取出业务方的事务
+func txFromContext(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*gorm.DB)
+	return tx, ok && tx != nil
+}
+
 // gormStore 是 Store 接口的 GORM 实现
 type gormStore struct {
 	db *gorm.DB
 }
 
+// AutoMigrate 创建或更新本包使用的表(事务消息表与收件箱表)。
+// 推荐在 bootstrap.AppInfoV2.Migrate 中调用，使迁移失败时服务在注册到 Nacos 之前就启动失败。
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Message{}, &InboxMessage{})
+}
+
 // NewGormStore 创建一个新的 GORM Store 实例
-// 这个 *gorm.DB 实例应该是从您的业务代码中已经初始化好的数据库连接
+// 这个 *gorm.DB 实例应该是从您的业务代码中已经初始化好的数据库连接。
+// 它不会建表，请在启动时(推荐在 bootstrap.AppInfoV2.Migrate 中)调用 AutoMigrate 确保表结构存在。
 func NewGormStore(db *gorm.DB) Store {
-	// 建议在启动时执行一次 AutoMigrate，以确保表结构存在
-	err := db.AutoMigrate(&Message{})
-	if err != nil {
-		// 在实际应用中，您可能需要更健壮的错误处理
-		panic(err)
-	}
 	return &gormStore{db: db}
 }
 
 func (s *gormStore) CreateInTx(ctx context.Context, msg *Message) error {
-	return s.db.WithContext(ctx).Create(msg).Error
+	db := s.db
+	if tx, ok := txFromContext(ctx); ok {
+		db = tx
+	}
+	return db.WithContext(ctx).Create(msg).Error
 }
 
 func (s *gormStore) FindPendingMessages(ctx context.Context, limit int) ([]*Message, error) {
@@ -56,3 +80,9 @@ func (s *gormStore) UpdateStatus(ctx context.Context, id int64, status Status, n
 		"retry_count": newRetryCount,
 	}).Error
 }
+
+func (s *gormStore) CountPendingMessages(ctx context.Context) (int64, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&Message{}).Where("status = ?", StatusPending).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,57 @@
+package transactional
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// HeaderOriginTraceparent 记录写入事务消息时(业务事务中)的 W3C traceparent。
+	// 转发时消息的 traceparent 会被替换为转发 span，消费端可以据此把自己的 span 链接回原始的业务操作。
+	HeaderOriginTraceparent = "outbox-origin-traceparent"
+	// HeaderOriginTracestate 记录写入事务消息时的 W3C tracestate
+	HeaderOriginTracestate = "outbox-origin-tracestate"
+)
+
+// originKeys 将 W3C 传播字段映射到保存在消息头中的键
+var originKeys = map[string]string{
+	"traceparent": HeaderOriginTraceparent,
+	"tracestate":  HeaderOriginTracestate,
+}
+
+// stampOriginTrace 返回补充了 ctx 中 trace context 的消息头副本，ctx 中没有有效的 span 时原样返回
+func stampOriginTrace(ctx context.Context, headers map[string]string) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return headers
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	stamped := make(map[string]string, len(headers)+len(carrier))
+	for k, v := range headers {
+		stamped[k] = v
+	}
+	for field, key := range originKeys {
+		if v := carrier.Get(field); v != "" {
+			stamped[key] = v
+		}
+	}
+	return stamped
+}
+
+// originLinks 从消息头中还原写入消息时的 span，返回指向它的 span link，没有记录时返回 nil
+func originLinks(headers map[string]string) []trace.Link {
+	carrier := propagation.MapCarrier{}
+	for field, key := range originKeys {
+		if v, ok := headers[key]; ok {
+			carrier.Set(field, v)
+		}
+	}
+	sc := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+	if !sc.IsValid() {
+		return nil
+	}
+	return []trace.Link{{SpanContext: sc}}
+}
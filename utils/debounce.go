@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Debouncer 将短时间内的多次触发合并为一次调用。
+// 典型场景是 Nacos 连续推送多次配置变更时，只在变更平息 wait 之后执行一次昂贵的重建逻辑。
+type Debouncer struct {
+	wait time.Duration
+	fn   func()
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	stopped bool
+}
+
+// NewDebouncer 创建一个防抖器。每次 Trigger 都会重置计时器，
+// 只有在最后一次 Trigger 之后 wait 时间内没有新的触发，fn 才会被调用一次。
+func NewDebouncer(wait time.Duration, fn func()) *Debouncer {
+	return &Debouncer{
+		wait: wait,
+		fn:   fn,
+	}
+}
+
+// Trigger 通知防抖器发生了一次变更。它是并发安全的，可以直接在配置回调中调用。
+func (d *Debouncer) Trigger() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timer = time.AfterFunc(d.wait, d.fn)
+}
+
+// Stop 取消尚未执行的调用，并使之后的 Trigger 失效。
+func (d *Debouncer) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopped = true
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
+
+// Debounce 是 NewDebouncer 的便捷包装，返回一个可以直接作为回调使用的触发函数。
+func Debounce(wait time.Duration, fn func()) func() {
+	return NewDebouncer(wait, fn).Trigger
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebouncerCoalescesConcurrentTriggers(t *testing.T) {
+	var calls atomic.Int32
+	d := NewDebouncer(50*time.Millisecond, func() { calls.Add(1) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				d.Trigger()
+			}
+		}()
+	}
+	wg.Wait()
+
+	time.Sleep(200 * time.Millisecond)
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("callback ran %d times, want 1", got)
+	}
+}
+
+func TestDebouncerRunsAgainAfterQuietPeriod(t *testing.T) {
+	var calls atomic.Int32
+	trigger := Debounce(20*time.Millisecond, func() { calls.Add(1) })
+
+	trigger()
+	time.Sleep(100 * time.Millisecond)
+	trigger()
+	time.Sleep(100 * time.Millisecond)
+
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("callback ran %d times, want 2", got)
+	}
+}
+
+func TestDebouncerStopCancelsPendingCall(t *testing.T) {
+	var calls atomic.Int32
+	d := NewDebouncer(20*time.Millisecond, func() { calls.Add(1) })
+
+	d.Trigger()
+	d.Stop()
+	d.Trigger()
+	time.Sleep(100 * time.Millisecond)
+
+	if got := calls.Load(); got != 0 {
+		t.Fatalf("callback ran %d times after Stop, want 0", got)
+	}
+}
@@ -3,11 +3,75 @@ package utils
 import (
 	"fmt"
 	"net"
+	"os"
+	"strconv"
 )
 
-// GetOutboundIP 获取本机的首选出站 IP 地址
-func GetOutboundIP() (string, error) {
-	conn, err := net.Dial("udp", "8.8.8.8:80")
+const (
+	// defaultOutboundTarget 是探测出站 IP 时拨号的目标地址。UDP 拨号不会真正发送数据包。
+	defaultOutboundTarget = "8.8.8.8:80"
+	// defaultOutboundTargetV6 是优先 IPv6 时的拨号目标地址
+	defaultOutboundTargetV6 = "[2001:4860:4860::8888]:80"
+)
+
+// advertiseIPEnvs 按优先级列出可以直接指定对外通告 IP 的环境变量
+var advertiseIPEnvs = []string{"NEXUS_ADVERTISE_IP", "POD_IP"}
+
+// IPOption 用于定制出站 IP 的选择
+type IPOption func(*ipOptions)
+
+type ipOptions struct {
+	preferIPv6 bool
+}
+
+// PreferIPv6 优先选择 IPv6 地址，适用于双栈集群。也可以通过 NEXUS_PREFER_IPV6=true 开启。
+func PreferIPv6() IPOption {
+	return func(o *ipOptions) {
+		o.preferIPv6 = true
+	}
+}
+
+func newIPOptions(opts []IPOption) ipOptions {
+	var o ipOptions
+	o.preferIPv6, _ = strconv.ParseBool(os.Getenv("NEXUS_PREFER_IPV6"))
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// GetOutboundIP 获取本机对外通告的 IP 地址，该地址会被注册到 Nacos。按以下顺序确定:
+//  1. NEXUS_ADVERTISE_IP、POD_IP 环境变量指定的地址，适用于多网卡主机和容器环境；
+//  2. NEXUS_ADVERTISE_INTERFACE 环境变量指定的网卡上的地址；
+//  3. 通过 UDP 拨号探测首选出站 IP，拨号目标可以通过 NEXUS_OUTBOUND_TARGET 指定，默认为 8.8.8.8:80(优先 IPv6 时为 Google 的 IPv6 DNS)。
+func GetOutboundIP(opts ...IPOption) (string, error) {
+	for _, key := range advertiseIPEnvs {
+		if value := os.Getenv(key); value != "" {
+			if net.ParseIP(value) == nil {
+				return "", fmt.Errorf("invalid IP %q in env %s", value, key)
+			}
+			return value, nil
+		}
+	}
+	if name := os.Getenv("NEXUS_ADVERTISE_INTERFACE"); name != "" {
+		return GetOutboundIPForInterface(name, opts...)
+	}
+
+	o := newIPOptions(opts)
+	target := os.Getenv("NEXUS_OUTBOUND_TARGET")
+	if target == "" {
+		target = defaultOutboundTarget
+		if o.preferIPv6 {
+			target = defaultOutboundTargetV6
+		}
+	}
+	return GetOutboundIPVia(target)
+}
+
+// GetOutboundIPVia 返回访问 target 时使用的本地 IP 地址。
+// 在无默认路由的隔离网络中，可以将 target 设置为内网中可达的地址(例如 Nacos 服务器)。
+func GetOutboundIPVia(target string) (string, error) {
+	conn, err := net.Dial("udp", target)
 	if err != nil {
 		return "", fmt.Errorf("failed to dial to get outbound IP: %w", err)
 	}
@@ -16,3 +80,36 @@ func GetOutboundIP() (string, error) {
 	localAddr := conn.LocalAddr().(*net.UDPAddr)
 	return localAddr.IP.String(), nil
 }
+
+// GetOutboundIPForInterface 返回指定网卡上的全局单播地址，默认优先 IPv4，使用 PreferIPv6 时优先 IPv6。
+// 首选地址族不存在时返回另一地址族的地址。
+func GetOutboundIPForInterface(name string, opts ...IPOption) (string, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to find interface %s: %w", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("failed to list addresses of interface %s: %w", name, err)
+	}
+
+	o := newIPOptions(opts)
+	var fallback net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		isV6 := ipNet.IP.To4() == nil
+		if isV6 == o.preferIPv6 {
+			return ipNet.IP.String(), nil
+		}
+		if fallback == nil {
+			fallback = ipNet.IP
+		}
+	}
+	if fallback == nil {
+		return "", fmt.Errorf("interface %s has no global unicast address", name)
+	}
+	return fallback.String(), nil
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy 描述重试的次数与退避策略
+type RetryPolicy struct {
+	// MaxAttempts 为最大尝试次数(包括第一次)，<= 0 时视为 1
+	MaxAttempts int
+	// BaseDelay 为第一次重试前的等待时间
+	BaseDelay time.Duration
+	// MaxDelay 为单次等待时间的上限，<= 0 表示不限制
+	MaxDelay time.Duration
+	// Multiplier 为每次重试后等待时间的放大倍数，<= 1 时使用固定间隔
+	Multiplier float64
+	// Jitter 为随机抖动比例，取值 [0, 1]，例如 0.2 表示在 ±20% 范围内随机，避免多个实例同时重试
+	Jitter float64
+}
+
+// DefaultRetryPolicy 返回一个通用的默认策略: 最多 5 次，间隔从 100ms 开始翻倍，最长 5 秒，抖动 20%
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Multiplier:  2,
+		Jitter:      0.2,
+	}
+}
+
+// Delay 返回第 attempt 次重试(从 1 开始)前应等待的时间
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := float64(p.BaseDelay)
+	if p.Multiplier > 1 {
+		for i := 1; i < attempt; i++ {
+			delay *= p.Multiplier
+			if p.MaxDelay > 0 && delay >= float64(p.MaxDelay) {
+				break
+			}
+		}
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+type nonRetryableError struct {
+	err error
+}
+
+func (e *nonRetryableError) Error() string { return e.err.Error() }
+func (e *nonRetryableError) Unwrap() error { return e.err }
+
+// NonRetryable 将错误标记为不可重试，Retry 收到这样的错误时立即返回
+func NonRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &nonRetryableError{err: err}
+}
+
+// IsNonRetryable 判断错误是否被标记为不可重试
+func IsNonRetryable(err error) bool {
+	var target *nonRetryableError
+	return errors.As(err, &target)
+}
+
+// Retry 按 policy 重试 fn，直到成功、尝试次数耗尽、fn 返回不可重试的错误或 ctx 被取消。
+// 返回 fn 的最后一个错误(不可重试错误会被解包)；ctx 被取消时返回包装了 ctx.Err() 的错误。
+func Retry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	attempts := max(policy.MaxAttempts, 1)
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			if lastErr != nil {
+				return fmt.Errorf("retry aborted: %w (last error: %v)", err, lastErr)
+			}
+			return err
+		}
+
+		lastErr = fn()
+		if lastErr == nil {
+			return nil
+		}
+		var nonRetryable *nonRetryableError
+		if errors.As(lastErr, &nonRetryable) {
+			return nonRetryable.err
+		}
+		if attempt == attempts {
+			break
+		}
+
+		timer := time.NewTimer(policy.Delay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("retry aborted: %w (last error: %v)", ctx.Err(), lastErr)
+		}
+	}
+	return lastErr
+}
@@ -3,6 +3,7 @@ package zookeeper
 
 import (
 	"github.com/wangyingjie930/nexus-pkg/logger"
+	"sync"
 	"time"
 
 	"github.com/go-zookeeper/zk"
@@ -11,6 +12,26 @@ import (
 // Conn 是一个包装了官方zk.Conn的结构体，可以附加更多应用逻辑
 type Conn struct {
 	*zk.Conn
+
+	// knownPaths 缓存已确认存在的持久节点路径，ensurePath 据此跳过重复的 Exists/Create 调用。
+	// 会话过期后节点可能已被删除或切换到了新的集群状态，缓存会被清空。
+	knownPaths sync.Map
+}
+
+// pathKnown 返回 path 是否已确认存在
+func (c *Conn) pathKnown(path string) bool {
+	_, ok := c.knownPaths.Load(path)
+	return ok
+}
+
+// rememberPath 记录 path 已存在
+func (c *Conn) rememberPath(path string) {
+	c.knownPaths.Store(path, struct{}{})
+}
+
+// forgetPaths 清空已知路径缓存
+func (c *Conn) forgetPaths() {
+	c.knownPaths.Clear()
 }
 
 var (
@@ -33,6 +54,8 @@ func InitZookeeper(servers []string) (*Conn, error) {
 		return nil, err
 	}
 
+	conn := &Conn{Conn: c}
+
 	// 启动一个goroutine来异步监听连接事件
 	go func() {
 		for event := range eventChan {
@@ -46,10 +69,11 @@ func InitZookeeper(servers []string) (*Conn, error) {
 				case zk.StateExpired:
 					// 会话过期通常意味着需要重新建立所有临时节点和Watcher
 					logger.Logger.Println("ZooKeeper session expired.")
+					conn.forgetPaths()
 				}
 			}
 		}
 	}()
 
-	return &Conn{c}, nil
+	return conn, nil
 }
@@ -0,0 +1,230 @@
+// internal/zookeeper/election.go
+package zookeeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-zookeeper/zk"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+)
+
+const (
+	electionRoot = "/elections" // 所有选举的根节点
+)
+
+// Election 基于临时顺序节点实现的 Leader 选举。
+// 每个候选者在选举路径下创建一个临时顺序节点，序号最小者成为 Leader，
+// 其余候选者只监听自己的前一个节点，避免羊群效应。
+//
+// 关于会话过期: Leader 身份绑定在 ZooKeeper 会话上。会话过期后临时节点会被服务端删除，
+// Election 会监听到自己节点的删除(或 Watcher 失效)，随即将 IsLeader 置为 false
+// 并通过 Changes 通知调用方，调用方需要停止单例任务并重新调用 Campaign 参与选举。
+// 注意在连接断开但会话尚未过期的窗口内，本实例仍会认为自己是 Leader，
+// 对强一致性有要求的任务应结合 Conn 的连接事件做额外保护。
+type Election struct {
+	conn *Conn
+	path string // 选举路径，例如 /elections/delay-scheduler
+
+	mu      sync.Mutex
+	node    string        // 自己创建的候选节点路径
+	stop    chan struct{} // 用于停止对自身节点的监听
+	leader  bool
+	changes chan bool
+}
+
+// NewElection 创建一个新的选举实例，name 标识一个独立的选举(通常是任务名)
+func NewElection(conn *Conn, name string) (*Election, error) {
+	electionPath := electionRoot + "/" + name
+	if err := ensurePath(conn, electionPath); err != nil {
+		return nil, fmt.Errorf("failed to ensure election path %s exists: %w", electionPath, err)
+	}
+	return &Election{
+		conn:    conn,
+		path:    electionPath,
+		changes: make(chan bool, 1),
+	}, nil
+}
+
+// Campaign 参与选举，阻塞直到当选 Leader 或 ctx 被取消。
+// ctx 被取消时会删除自己的候选节点并返回 ctx.Err()。
+func (e *Election) Campaign(ctx context.Context) error {
+	e.mu.Lock()
+	if e.node != "" {
+		e.mu.Unlock()
+		return errors.New("election campaign already in progress")
+	}
+	nodePath, err := e.conn.CreateProtectedEphemeralSequential(e.path+"/candidate-", []byte(""), zk.WorldACL(zk.PermAll))
+	if err != nil {
+		e.mu.Unlock()
+		return fmt.Errorf("failed to create candidate node: %w", err)
+	}
+	e.node = nodePath
+	e.mu.Unlock()
+
+	myNodeName := strings.TrimPrefix(nodePath, e.path+"/")
+	for {
+		children, _, err := e.conn.Children(e.path)
+		if err != nil {
+			e.abandon()
+			return fmt.Errorf("failed to get candidate nodes: %w", err)
+		}
+		// 受保护节点带有 GUID 前缀，必须按序号而不是按名称排序
+		sort.Slice(children, func(i, j int) bool {
+			return sequenceOf(children[i]) < sequenceOf(children[j])
+		})
+
+		myIndex := -1
+		for i, child := range children {
+			if child == myNodeName {
+				myIndex = i
+				break
+			}
+		}
+		if myIndex < 0 {
+			// 自己的节点已经不存在(通常是会话过期)，需要重新参与选举
+			e.abandon()
+			return errors.New("candidate node disappeared, session may have expired")
+		}
+		if myIndex == 0 {
+			e.becomeLeader(nodePath)
+			return nil
+		}
+
+		prevNodePath := e.path + "/" + children[myIndex-1]
+		exists, _, eventChan, err := e.conn.ExistsW(prevNodePath)
+		if err != nil {
+			e.abandon()
+			return fmt.Errorf("failed to watch previous candidate: %w", err)
+		}
+		if !exists {
+			continue
+		}
+
+		select {
+		case <-eventChan:
+			// 前一个节点发生变化，重新检查自己的位置
+		case <-ctx.Done():
+			e.abandon()
+			return ctx.Err()
+		}
+	}
+}
+
+// Resign 主动放弃 Leader 身份(或退出选举)，删除自己的候选节点
+func (e *Election) Resign() error {
+	e.mu.Lock()
+	node := e.node
+	e.mu.Unlock()
+	if node == "" {
+		return errors.New("not participating in election")
+	}
+	err := e.conn.Delete(node, -1)
+	if err != nil && err != zk.ErrNoNode {
+		return fmt.Errorf("failed to delete candidate node: %w", err)
+	}
+	e.lose(node)
+	return nil
+}
+
+// IsLeader 返回当前实例是否为 Leader
+func (e *Election) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leader
+}
+
+// Changes 返回 Leader 身份变化的通知通道。通道只保留最新的状态，
+// 当选时收到 true，失去 Leader 身份(主动放弃或会话过期)时收到 false。
+func (e *Election) Changes() <-chan bool {
+	return e.changes
+}
+
+// becomeLeader 标记当选，并开始监听自身节点以感知会话过期
+func (e *Election) becomeLeader(node string) {
+	e.mu.Lock()
+	e.leader = true
+	e.stop = make(chan struct{})
+	stop := e.stop
+	e.notify(true)
+	e.mu.Unlock()
+
+	logger.Logger.Printf("✅ Elected as leader for '%s' (%s)", e.path, node)
+	go e.watchSelf(node, stop)
+}
+
+// watchSelf 监听自己的候选节点，节点被删除或 Watcher 失效即视为失去 Leader 身份
+func (e *Election) watchSelf(node string, stop chan struct{}) {
+	for {
+		exists, _, eventChan, err := e.conn.ExistsW(node)
+		if err != nil || !exists {
+			e.lose(node)
+			return
+		}
+		select {
+		case event := <-eventChan:
+			if event.Type == zk.EventNodeDeleted || event.Type == zk.EventNotWatching {
+				logger.Logger.Printf("⚠️ Leadership for '%s' lost (%s)", e.path, event.Type)
+				e.lose(node)
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// abandon 在竞选失败时清理自己的候选节点
+func (e *Election) abandon() {
+	e.mu.Lock()
+	node := e.node
+	e.node = ""
+	e.mu.Unlock()
+	if node != "" {
+		_ = e.conn.Delete(node, -1)
+	}
+}
+
+// lose 清理 Leader 状态并发出通知，node 用于忽略过期的回调
+func (e *Election) lose(node string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.node != node {
+		return
+	}
+	e.node = ""
+	if e.stop != nil {
+		close(e.stop)
+		e.stop = nil
+	}
+	if e.leader {
+		e.leader = false
+		e.notify(false)
+	}
+}
+
+// notify 向 changes 发送最新状态，丢弃尚未被消费的旧状态。调用方需持有 mu。
+func (e *Election) notify(leader bool) {
+	select {
+	case <-e.changes:
+	default:
+	}
+	e.changes <- leader
+}
+
+// sequenceOf 解析顺序节点名称末尾的 10 位序号
+func sequenceOf(name string) int64 {
+	if len(name) < 10 {
+		return -1
+	}
+	seq, err := strconv.ParseInt(name[len(name)-10:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return seq
+}
@@ -2,120 +2,401 @@
 package zookeeper
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-zookeeper/zk"
+	"github.com/wangyingjie930/nexus-pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	lockRoot = "/distributed_locks" // 所有分布式锁的根节点
+	lockRoot        = "/distributed_locks" // 所有分布式锁的根节点
+	lockWaitTimeout = 30 * time.Second     // Lock 的最长等待时间，防止死等
 )
 
 // DistributedLock 定义了一个分布式锁对象
+//
+// 锁绑定在 ZooKeeper 会话上: 会话过期后锁节点会被服务端删除，锁可能在临界区执行期间丢失。
+// 长时间运行的临界区应监听 Lost() 并在锁丢失时中止工作；写入外部存储时应携带 Lock 返回的
+// fencing token，由存储端拒绝 token 小于已见最大值的写入，从而避免脑裂。
+//
+// 默认不可重入。通过 WithOwner 指定持有者标识后，同一进程内同一持有者对同一资源的嵌套加锁(无论是否使用同一个
+// DistributedLock 实例)会直接成功并增加计数，只有最后一次 Unlock 才会真正释放锁，见 WithOwner。
 type DistributedLock struct {
-	conn     *Conn  // ZooKeeper连接
-	path     string // 锁的路径，例如 /distributed_locks/item-123
-	lockNode string // 成功获取锁后，自己创建的节点路径
+	conn *Conn  // ZooKeeper连接
+	path string // 锁的路径，例如 /distributed_locks/item-123
+
+	mu       sync.Mutex
+	lockNode string        // 成功获取锁后，自己创建的节点路径
+	token    int64         // 持有锁时的 fencing token，即锁节点的序号
+	lost     chan struct{} // 锁丢失(节点被删除或会话过期)时关闭
+	stop     chan struct{} // Unlock 时关闭，用于停止对锁节点的监听
+
+	owner string // 可重入加锁的持有者标识，为空表示不可重入
+	holds int    // 持有锁期间的重入次数，真正持有时至少为 1
+	// 通过另一个实例重入时，delegate 为真正持有锁的实例，delegated 为尚未释放的重入次数
+	delegate  *DistributedLock
+	delegated int
+}
+
+var (
+	reentrantMu sync.Mutex
+	// 以 WithOwner 获取的锁，按锁路径索引，用于让同一持有者在其他实例上重入
+	reentrantLocks = make(map[string]*DistributedLock)
+)
+
+// LockTimeoutError 在等待锁超时时返回，记录超时时排在前面的节点数，便于判断锁竞争的程度。
+// 它可以被 errors.Is(err, context.DeadlineExceeded) 识别。
+type LockTimeoutError struct {
+	Path string
+	// Ahead 为超时时排在自己前面的节点数(包括当前持有者)，-1 表示尚未进入队列
+	Ahead int
+	err   error
+}
+
+func (e *LockTimeoutError) Error() string {
+	return fmt.Sprintf("timeout waiting for lock %s (%d holders ahead)", e.Path, e.Ahead)
+}
+
+func (e *LockTimeoutError) Unwrap() error {
+	return e.err
+}
+
+// LockOption 用于定制一次加锁
+type LockOption func(*lockOptions)
+
+type lockOptions struct {
+	timeout    time.Duration
+	onPosition func(ahead int)
+	owner      string
+}
+
+// WithTimeout 指定 Lock 的最长等待时间，默认 30 秒。对 LockContext 而言它与 ctx 的截止时间取较早者。
+func WithTimeout(d time.Duration) LockOption {
+	return func(o *lockOptions) {
+		o.timeout = d
+	}
+}
+
+// WithQueuePosition 在每次检查排队情况时回调 fn，ahead 为排在自己前面的节点数(包括当前持有者)，
+// 获取到锁时 ahead 为 0。排队位置同时会作为 lock.queue_position 事件记录到 ctx 中的 span 上。
+func WithQueuePosition(fn func(ahead int)) LockOption {
+	return func(o *lockOptions) {
+		o.onPosition = fn
+	}
+}
+
+// WithOwner 开启可重入加锁，owner 标识锁的持有者: 锁已经由同一 owner 在本进程中持有时，加锁直接成功并增加计数，
+// 返回同一个 fencing token；每次成功的加锁都需要对应一次 Unlock，最后一次 Unlock 才会删除锁节点。
+//
+// Go 没有 goroutine 本地存储，持有者标识必须由调用方显式传递，且在整个调用链中保持一致，例如请求 ID 或任务 ID。
+// owner 必须能唯一标识一个逻辑执行者: 两个并发的 goroutine 使用相同的 owner 会同时"持有"锁，互斥将不再成立。
+// 重入只对嵌套(顺序发生)的加锁有效，同一 owner 的并发加锁仍会互相等待。
+func WithOwner(owner string) LockOption {
+	return func(o *lockOptions) {
+		o.owner = owner
+	}
 }
 
-// NewDistributedLock 创建一个新的分布式锁实例
-func NewDistributedLock(conn *Conn, resourceID string) *DistributedLock {
+// NewDistributedLock 创建一个新的分布式锁实例。
+// 确保锁路径存在失败(例如 ZooKeeper 暂时不可达)时返回错误，由调用方决定重试还是降级。
+func NewDistributedLock(conn *Conn, resourceID string) (*DistributedLock, error) {
 	lockPath := lockRoot + "/" + resourceID
 
-	// <<<<<<< 修改点: 使用 ensurePath 替换原有的创建逻辑 >>>>>>>>>
 	// 确保锁的根路径和资源路径都存在
 	if err := ensurePath(conn, lockPath); err != nil {
-		// 如果路径创建失败，这是一个严重问题，直接panic
-		panic(fmt.Sprintf("Failed to ensure lock path %s exists: %v", lockPath, err))
+		return nil, fmt.Errorf("failed to ensure lock path %s exists: %w", lockPath, err)
 	}
-	// <<<<<<< 修改结束 >>>>>>>>>
 
 	return &DistributedLock{
 		conn: conn,
 		path: lockPath,
-	}
+	}, nil
+}
+
+// Lock 尝试获取锁，如果获取不到则阻塞等待，默认最多等待 30 秒(可通过 WithTimeout 修改)。
+// 获取成功后返回 fencing token，见 LockContext；超时时返回 *LockTimeoutError。
+func (l *DistributedLock) Lock(opts ...LockOption) (int64, error) {
+	return l.LockContext(context.Background(), append([]LockOption{WithTimeout(lockWaitTimeout)}, opts...)...)
 }
 
-// Lock 尝试获取锁，如果获取不到则阻塞等待
-func (l *DistributedLock) Lock() error {
+// LockContext 尝试获取锁，阻塞直到获取成功或 ctx 被取消。ctx 被取消时会删除自己创建的节点并返回 ctx.Err()，
+// 等待超时时返回包装了 context.DeadlineExceeded 的 *LockTimeoutError，其中记录了超时时的排队位置。
+//
+// 获取成功后返回 fencing token: 它是锁节点的序号，对同一资源单调递增，后获得锁的持有者 token 一定更大。
+func (l *DistributedLock) LockContext(ctx context.Context, opts ...LockOption) (int64, error) {
+	var o lockOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	span := trace.SpanFromContext(ctx)
+	ahead := -1
+	report := func(n int) {
+		ahead = n
+		span.AddEvent("lock.queue_position", trace.WithAttributes(
+			attribute.String("lock.path", l.path),
+			attribute.Int("lock.ahead", n),
+		))
+		if o.onPosition != nil {
+			o.onPosition(n)
+		}
+	}
+
+	if o.owner != "" {
+		if token, ok := l.reenter(o.owner); ok {
+			report(0)
+			return token, nil
+		}
+	}
+
+	l.mu.Lock()
+	if l.lockNode != "" {
+		l.mu.Unlock()
+		return 0, errors.New("lock already held")
+	}
+	l.mu.Unlock()
+
 	// 1. 在锁路径下创建一个临时顺序节点
 	// 格式为: /distributed_locks/resourceID/lock-
 	nodePath, err := l.conn.CreateProtectedEphemeralSequential(l.path+"/lock-", []byte(""), zk.WorldACL(zk.PermAll))
 	if err != nil {
-		return fmt.Errorf("failed to create sequential node: %w", err)
+		return 0, fmt.Errorf("failed to create sequential node: %w", err)
 	}
-	l.lockNode = nodePath
+	// 获取失败时删除自己的节点，避免阻塞后来的竞争者
+	abandon := func() { _ = l.conn.Delete(nodePath, -1) }
 
+	myNodeName := strings.TrimPrefix(nodePath, l.path+"/")
 	for {
 		// 2. 获取锁路径下的所有子节点
 		children, _, err := l.conn.Children(l.path)
 		if err != nil {
-			return fmt.Errorf("failed to get children nodes: %w", err)
+			abandon()
+			return 0, fmt.Errorf("failed to get children nodes: %w", err)
 		}
-		sort.Strings(children) // 排序，保证顺序
+		// 受保护节点带有 GUID 前缀，必须按序号而不是按名称排序
+		sort.Slice(children, func(i, j int) bool {
+			return sequenceOf(children[i]) < sequenceOf(children[j])
+		})
 
 		// 3. 判断自己是否是最小的节点
-		myNodeName := strings.TrimPrefix(l.lockNode, l.path+"/")
-		if myNodeName == children[0] {
-			// 是最小节点，成功获取锁
-			return nil
-		}
-
-		// 4. 不是最小节点，监听前一个节点
-		prevNodeIndex := -1
+		myIndex := -1
 		for i, child := range children {
 			if child == myNodeName {
-				prevNodeIndex = i - 1
+				myIndex = i
 				break
 			}
 		}
-		if prevNodeIndex < 0 {
-			return errors.New("cannot find previous node, something is wrong")
+		if myIndex < 0 {
+			return 0, errors.New("lock node disappeared, session may have expired")
 		}
-		prevNodePath := l.path + "/" + children[prevNodeIndex]
+		report(myIndex)
+		if myIndex == 0 {
+			// 是最小节点，成功获取锁
+			return l.acquired(nodePath, sequenceOf(myNodeName), o.owner), nil
+		}
+
+		// 4. 不是最小节点，监听前一个节点
+		prevNodePath := l.path + "/" + children[myIndex-1]
 
 		// 使用 ExistsW 来设置一次性的Watcher
-		_, _, eventChan, err := l.conn.ExistsW(prevNodePath)
+		exists, _, eventChan, err := l.conn.ExistsW(prevNodePath)
 		if err != nil {
-			// 如果在前一个节点检查时它刚好被删除了，就重试循环
-			if err == zk.ErrNoNode {
-				continue
-			}
-			return fmt.Errorf("failed to watch previous node: %w", err)
+			abandon()
+			return 0, fmt.Errorf("failed to watch previous node: %w", err)
+		}
+		// 如果在前一个节点检查时它刚好被删除了，就重试循环
+		if !exists {
+			continue
 		}
 
 		// 阻塞等待事件
 		select {
+		case <-eventChan:
+			// 前一个节点发生变化，重新进入循环去竞争锁
+		case <-ctx.Done():
+			abandon()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return 0, &LockTimeoutError{Path: l.path, Ahead: ahead, err: ctx.Err()}
+			}
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// reenter 在锁已由 owner 在本进程中持有时增加重入计数，并返回持有者的 fencing token
+func (l *DistributedLock) reenter(owner string) (int64, bool) {
+	reentrantMu.Lock()
+	defer reentrantMu.Unlock()
+	holder, ok := reentrantLocks[l.path]
+	if !ok || holder.owner != owner {
+		return 0, false
+	}
+	holder.mu.Lock()
+	holder.holds++
+	token := holder.token
+	holder.mu.Unlock()
+
+	if holder != l {
+		l.mu.Lock()
+		l.delegate = holder
+		l.delegated++
+		l.mu.Unlock()
+	}
+	return token, true
+}
+
+// acquired 记录持有锁的状态，并开始监听锁节点以感知锁丢失
+func (l *DistributedLock) acquired(node string, token int64, owner string) int64 {
+	reentrantMu.Lock()
+	l.mu.Lock()
+	l.lockNode = node
+	l.token = token
+	l.owner = owner
+	l.holds = 1
+	l.lost = make(chan struct{})
+	l.stop = make(chan struct{})
+	lost, stop := l.lost, l.stop
+	l.mu.Unlock()
+	if owner != "" {
+		reentrantLocks[l.path] = l
+	}
+	reentrantMu.Unlock()
+
+	go l.watchHeld(node, lost, stop)
+	return token
+}
+
+// watchHeld 监听持有的锁节点，节点被删除或 Watcher 失效(会话过期)即视为锁丢失
+func (l *DistributedLock) watchHeld(node string, lost, stop chan struct{}) {
+	for {
+		exists, _, eventChan, err := l.conn.ExistsW(node)
+		if err != nil || !exists {
+			l.markLost(node, lost)
+			return
+		}
+		select {
 		case event := <-eventChan:
-			// 如果前一个节点被删除，我们就收到通知，重新进入循环去竞争锁
-			if event.Type == zk.EventNodeDeleted {
-				continue
+			if event.Type == zk.EventNodeDeleted || event.Type == zk.EventNotWatching {
+				logger.Logger.Printf("⚠️ Distributed lock '%s' lost (%s)", l.path, event.Type)
+				l.markLost(node, lost)
+				return
 			}
-		case <-time.After(30 * time.Second): // 设置超时，防止死等
-			return errors.New("timeout waiting for lock")
+		case <-stop:
+			return
 		}
 	}
 }
 
-// Unlock 释放锁
+// markLost 关闭 lost 通知调用方；Unlock 之后的过期回调会被忽略
+func (l *DistributedLock) markLost(node string, lost chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lockNode != node {
+		return
+	}
+	close(lost)
+}
+
+// Lost 返回一个在锁丢失时关闭的通道。临界区可以这样在失去锁时及时中止:
+//
+//	select {
+//	case <-lock.Lost():
+//		return errors.New("lock lost")
+//	default:
+//	}
+//
+// 尚未持有锁时返回一个已关闭的通道；通过其他实例重入时返回真正持有锁的实例的通道。
+func (l *DistributedLock) Lost() <-chan struct{} {
+	l.mu.Lock()
+	if delegate := l.delegate; delegate != nil {
+		l.mu.Unlock()
+		return delegate.Lost()
+	}
+	defer l.mu.Unlock()
+	if l.lost == nil {
+		closed := make(chan struct{})
+		close(closed)
+		return closed
+	}
+	return l.lost
+}
+
+// FencingToken 返回当前持有锁的 fencing token，未持有锁时返回 0
+func (l *DistributedLock) FencingToken() int64 {
+	l.mu.Lock()
+	if delegate := l.delegate; delegate != nil {
+		l.mu.Unlock()
+		return delegate.FencingToken()
+	}
+	defer l.mu.Unlock()
+	return l.token
+}
+
+// Unlock 释放锁。可重入加锁时每次 Unlock 减少一次计数，计数归零时才真正删除锁节点。
 func (l *DistributedLock) Unlock() error {
-	if l.lockNode == "" {
+	reentrantMu.Lock()
+	l.mu.Lock()
+	if l.delegated > 0 {
+		// 通过本实例在其他实例上的重入，释放一次持有者的计数
+		holder := l.delegate
+		l.delegated--
+		if l.delegated == 0 {
+			l.delegate = nil
+		}
+		l.mu.Unlock()
+		reentrantMu.Unlock()
+		return holder.Unlock()
+	}
+	node := l.lockNode
+	if node == "" {
+		l.mu.Unlock()
+		reentrantMu.Unlock()
 		return errors.New("no lock to unlock")
 	}
-	err := l.conn.Delete(l.lockNode, -1)
+	if l.holds > 1 {
+		l.holds--
+		l.mu.Unlock()
+		reentrantMu.Unlock()
+		return nil
+	}
+	if reentrantLocks[l.path] == l {
+		delete(reentrantLocks, l.path)
+	}
+	l.lockNode = ""
+	l.token = 0
+	l.owner = ""
+	l.holds = 0
+	l.lost = nil
+	close(l.stop)
+	l.stop = nil
+	l.mu.Unlock()
+	reentrantMu.Unlock()
+
+	err := l.conn.Delete(node, -1)
 	if err != nil && err != zk.ErrNoNode {
 		return fmt.Errorf("failed to delete lock node: %w", err)
 	}
-	l.lockNode = ""
 	return nil
 }
 
 // 新增一个辅助函数，确保路径存在 (类似 mkdir -p)
+// 已确认存在的路径缓存在 Conn 中，热点资源重复创建锁时不再产生额外的 ZooKeeper 往返。
 func ensurePath(conn *Conn, path string) error {
+	if conn.pathKnown(path) {
+		return nil
+	}
 	parts := strings.Split(path, "/")
 	currentPath := ""
 	for _, part := range parts {
@@ -123,6 +404,9 @@ func ensurePath(conn *Conn, path string) error {
 			continue
 		}
 		currentPath += "/" + part
+		if conn.pathKnown(currentPath) {
+			continue
+		}
 		exists, _, err := conn.Exists(currentPath)
 		if err != nil {
 			return fmt.Errorf("failed to check existence of path %s: %w", currentPath, err)
@@ -134,6 +418,7 @@ func ensurePath(conn *Conn, path string) error {
 				return fmt.Errorf("failed to create path %s: %w", currentPath, err)
 			}
 		}
+		conn.rememberPath(currentPath)
 	}
 	return nil
 }